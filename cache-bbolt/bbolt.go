@@ -0,0 +1,438 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package bbolt
+
+import (
+	"context"
+	"embed"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/apache/answer-plugins/cache-bbolt/i18n"
+	"github.com/apache/answer-plugins/util"
+	"github.com/apache/answer/plugin"
+	"github.com/segmentfault/pacman/log"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	dbFileName           = "cache.db"
+	openTimeout          = time.Second
+	defaultSweepInterval = time.Minute
+	// sweepBatchSize is the max number of expired keys removed in one write transaction
+	sweepBatchSize = 1000
+	// expireAtSize is the size of the expiration header stored before each value
+	expireAtSize = 8
+)
+
+var (
+	configuredErr = fmt.Errorf("bbolt cache is not configured correctly")
+	bucketName    = []byte("cache")
+	//go:embed  info.yaml
+	Info embed.FS
+)
+
+type Cache struct {
+	// mu guards the database, the cache methods hold the read lock while they use it,
+	// so a config change never closes the database under a running transaction
+	mu sync.RWMutex
+	// configMu serializes the config changes and the sweeper restarts
+	configMu sync.Mutex
+
+	Config *CacheConfig
+	DB     *bolt.DB
+
+	stopSweeper chan struct{}
+	sweeperDone chan struct{}
+}
+
+type CacheConfig struct {
+	Directory     string `json:"directory"`
+	SweepInterval string `json:"sweep_interval"`
+}
+
+func init() {
+	plugin.Register(&Cache{
+		Config: &CacheConfig{},
+	})
+}
+
+func (c *Cache) Info() plugin.Info {
+	info := &util.Info{}
+	info.GetInfo(Info)
+
+	return plugin.Info{
+		Name:        plugin.MakeTranslator(i18n.InfoName),
+		SlugName:    info.SlugName,
+		Description: plugin.MakeTranslator(i18n.InfoDescription),
+		Author:      info.Author,
+		Version:     info.Version,
+		Link:        info.Link,
+	}
+}
+
+func (c *Cache) GetString(ctx context.Context, key string) (data string, exist bool, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.getString(key)
+}
+
+func (c *Cache) getString(key string) (data string, exist bool, err error) {
+	if c.DB == nil {
+		return "", false, configuredErr
+	}
+	err = c.DB.View(func(tx *bolt.Tx) error {
+		value, _, ok := getEntry(tx.Bucket(bucketName), key)
+		if ok {
+			// the value is only valid during the transaction, so it must be copied
+			data, exist = string(value), true
+		}
+		return nil
+	})
+	return data, exist, err
+}
+
+func (c *Cache) SetString(ctx context.Context, key, value string, ttl time.Duration) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.setString(key, value, ttl)
+}
+
+func (c *Cache) setString(key, value string, ttl time.Duration) error {
+	if c.DB == nil {
+		return configuredErr
+	}
+	return c.DB.Update(func(tx *bolt.Tx) error {
+		return putEntry(tx.Bucket(bucketName), key, []byte(value), expireAt(ttl))
+	})
+}
+
+func (c *Cache) GetInt64(ctx context.Context, key string) (data int64, exist bool, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	value, exist, err := c.getString(key)
+	if err != nil || !exist {
+		return 0, false, err
+	}
+	data, err = strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return data, true, nil
+}
+
+func (c *Cache) SetInt64(ctx context.Context, key string, value int64, ttl time.Duration) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.setString(key, strconv.FormatInt(value, 10), ttl)
+}
+
+func (c *Cache) Increase(ctx context.Context, key string, value int64) (data int64, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.DB == nil {
+		return 0, configuredErr
+	}
+	return c.incrBy(key, value)
+}
+
+func (c *Cache) Decrease(ctx context.Context, key string, value int64) (data int64, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.DB == nil {
+		return 0, configuredErr
+	}
+	return c.incrBy(key, -value)
+}
+
+func (c *Cache) Del(ctx context.Context, key string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.DB == nil {
+		return configuredErr
+	}
+	return c.DB.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketName).Delete([]byte(key))
+	})
+}
+
+func (c *Cache) Flush(ctx context.Context) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.DB == nil {
+		return configuredErr
+	}
+	return c.DB.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(bucketName); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket(bucketName)
+		return err
+	})
+}
+
+// incrBy adds delta to the counter in a write transaction, which is serialized by bbolt.
+// Like redis, a missing counter starts from zero and an existing one keeps its expiration.
+func (c *Cache) incrBy(key string, delta int64) (data int64, err error) {
+	err = c.DB.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketName)
+		value, expiration, ok := getEntry(bucket, key)
+		if ok {
+			data, err = strconv.ParseInt(string(value), 10, 64)
+			if err != nil {
+				return fmt.Errorf("value is not an integer: %w", err)
+			}
+		} else {
+			expiration = 0
+		}
+		data += delta
+		return putEntry(bucket, key, []byte(strconv.FormatInt(data, 10)), expiration)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return data, nil
+}
+
+// getEntry returns the value and expiration of the key if it exists and is not expired
+func getEntry(bucket *bolt.Bucket, key string) (value []byte, expiration int64, ok bool) {
+	entry := bucket.Get([]byte(key))
+	if len(entry) < expireAtSize {
+		return nil, 0, false
+	}
+	expiration = int64(binary.BigEndian.Uint64(entry))
+	if isExpired(expiration, time.Now()) {
+		return nil, 0, false
+	}
+	return entry[expireAtSize:], expiration, true
+}
+
+func putEntry(bucket *bolt.Bucket, key string, value []byte, expiration int64) error {
+	entry := make([]byte, expireAtSize+len(value))
+	binary.BigEndian.PutUint64(entry, uint64(expiration))
+	copy(entry[expireAtSize:], value)
+	return bucket.Put([]byte(key), entry)
+}
+
+// expireAt returns the expiration in unix nanoseconds, 0 means never expire
+func expireAt(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return time.Now().Add(ttl).UnixNano()
+}
+
+func isExpired(expiration int64, now time.Time) bool {
+	return expiration > 0 && expiration <= now.UnixNano()
+}
+
+func (c *Cache) ConfigFields() []plugin.ConfigField {
+	return []plugin.ConfigField{
+		{
+			Name:        "directory",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigDirectoryTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigDirectoryDescription),
+			Required:    true,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.Directory,
+		},
+		{
+			Name:        "sweep_interval",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigSweepIntervalTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigSweepIntervalDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.SweepInterval,
+		},
+	}
+}
+
+func (c *Cache) ConfigReceiver(config []byte) error {
+	conf := &CacheConfig{}
+	_ = json.Unmarshal(config, conf)
+	if len(conf.Directory) == 0 {
+		return fmt.Errorf("bbolt cache directory is required")
+	}
+	path, err := filepath.Abs(filepath.Join(conf.Directory, dbFileName))
+	if err != nil {
+		return fmt.Errorf("bbolt cache directory is invalid: %w", err)
+	}
+
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
+	db := c.DB
+	// the database file is locked while open, so an unchanged directory keeps the open database
+	if db == nil || db.Path() != path {
+		if db, err = openDB(conf.Directory, path); err != nil {
+			return err
+		}
+	}
+
+	c.stopSweep()
+	c.mu.Lock()
+	old := c.DB
+	c.Config, c.DB = conf, db
+	c.mu.Unlock()
+	// no transaction uses the old database anymore once the write lock was taken
+	if old != nil && old != db {
+		_ = old.Close()
+	}
+	c.startSweep(db, conf.sweepInterval())
+	return nil
+}
+
+func openDB(directory, path string) (*bolt.DB, error) {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, fmt.Errorf("create bbolt cache directory failed: %w", err)
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("open bbolt cache failed: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketName)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("create bbolt cache bucket failed: %w", err)
+	}
+	return db, nil
+}
+
+func (c *Cache) close() {
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
+	c.stopSweep()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.DB != nil {
+		_ = c.DB.Close()
+		c.DB = nil
+	}
+}
+
+func (c *Cache) startSweep(db *bolt.DB, interval time.Duration) {
+	c.stopSweeper, c.sweeperDone = make(chan struct{}), make(chan struct{})
+	go c.runSweeper(db, interval, c.stopSweeper, c.sweeperDone)
+}
+
+// stopSweep stops the running sweeper and waits for it, so its database can be closed
+func (c *Cache) stopSweep() {
+	if c.stopSweeper == nil {
+		return
+	}
+	close(c.stopSweeper)
+	<-c.sweeperDone
+	c.stopSweeper, c.sweeperDone = nil, nil
+}
+
+func (conf *CacheConfig) sweepInterval() time.Duration {
+	seconds, _ := strconv.Atoi(conf.SweepInterval)
+	if seconds <= 0 {
+		return defaultSweepInterval
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// runSweeper removes the expired entries periodically until stop is closed, then closes done.
+// Expired entries are never returned, the sweeper only reclaims their space.
+func (c *Cache) runSweeper(db *bolt.DB, interval time.Duration, stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := sweep(db); err != nil {
+				log.Errorf("sweep expired bbolt cache failed: %v", err)
+			}
+		}
+	}
+}
+
+// sweep removes the expired entries in batches. Expired keys are found in read transactions
+// and only deleted in short write transactions, so the cache is never blocked for long.
+func sweep(db *bolt.DB) error {
+	var seek []byte
+	for {
+		var expired [][]byte
+		err := db.View(func(tx *bolt.Tx) error {
+			now := time.Now()
+			cursor := tx.Bucket(bucketName).Cursor()
+			k, v := cursor.First()
+			if seek != nil {
+				k, v = cursor.Seek(seek)
+			}
+			seek = nil
+			for ; k != nil; k, v = cursor.Next() {
+				if len(expired) >= sweepBatchSize {
+					seek = append([]byte{}, k...)
+					break
+				}
+				if len(v) >= expireAtSize && isExpired(int64(binary.BigEndian.Uint64(v)), now) {
+					expired = append(expired, append([]byte{}, k...))
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(expired) > 0 {
+			err = db.Update(func(tx *bolt.Tx) error {
+				bucket := tx.Bucket(bucketName)
+				now := time.Now()
+				for _, key := range expired {
+					// the key may have been set again since it was found
+					v := bucket.Get(key)
+					if len(v) < expireAtSize || !isExpired(int64(binary.BigEndian.Uint64(v)), now) {
+						continue
+					}
+					if err := bucket.Delete(key); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if seek == nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,194 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package bbolt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func newTestCache(t *testing.T, dir string) *Cache {
+	data, _ := json.Marshal(&CacheConfig{Directory: dir})
+	c := &Cache{Config: &CacheConfig{}}
+	if err := c.ConfigReceiver(data); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.close)
+	return c
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	c := newTestCache(t, dir)
+
+	if err := c.SetString(ctx, "string", "value", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetInt64(ctx, "expired", 1, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * time.Millisecond)
+	if _, exist, _ := c.GetInt64(ctx, "expired"); exist {
+		t.Fatal("expired key was returned")
+	}
+
+	if n, err := c.Increase(ctx, "counter", 2); err != nil || n != 2 {
+		t.Fatalf("Increase() on missing key = %d, %v", n, err)
+	}
+	if n, err := c.Decrease(ctx, "counter", 5); err != nil || n != -3 {
+		t.Fatalf("Decrease() = %d, %v", n, err)
+	}
+	if _, err := c.Increase(ctx, "string", 1); err == nil {
+		t.Fatal("Increase() on a string expected error")
+	}
+
+	// entries are persisted across restarts
+	c.close()
+	c = newTestCache(t, dir)
+	if data, exist, err := c.GetString(ctx, "string"); err != nil || !exist || data != "value" {
+		t.Fatalf("GetString() after reopen = %q, %v, %v", data, exist, err)
+	}
+	if n, exist, err := c.GetInt64(ctx, "counter"); err != nil || !exist || n != -3 {
+		t.Fatalf("GetInt64() after reopen = %d, %v, %v", n, exist, err)
+	}
+
+	if err := c.Del(ctx, "string"); err != nil {
+		t.Fatal(err)
+	}
+	if _, exist, _ := c.GetString(ctx, "string"); exist {
+		t.Fatal("key still exists after Del")
+	}
+	if err := c.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, exist, _ := c.GetInt64(ctx, "counter"); exist {
+		t.Fatal("key still exists after Flush")
+	}
+}
+
+func TestCache_ConcurrentIncrease(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t, t.TempDir())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if _, err := c.Increase(ctx, "counter", 1); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n, _, _ := c.GetInt64(ctx, "counter"); n != 200 {
+		t.Fatalf("GetInt64() = %d, want 200", n)
+	}
+}
+
+func TestCache_Reconfigure(t *testing.T) {
+	ctx := context.Background()
+	first, second := t.TempDir(), t.TempDir()
+	c := newTestCache(t, first)
+	if err := c.SetString(ctx, "key", "first", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if _, _, err := c.GetString(ctx, "key"); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	for _, dir := range []string{first, second, second, first} {
+		data, _ := json.Marshal(&CacheConfig{Directory: dir, SweepInterval: "1"})
+		if err := c.ConfigReceiver(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	if data, exist, _ := c.GetString(ctx, "key"); !exist || data != "first" {
+		t.Fatalf("GetString() after reconfigure = %q, %v", data, exist)
+	}
+	// a failed config change keeps the open database
+	data, _ := json.Marshal(&CacheConfig{})
+	if err := c.ConfigReceiver(data); err == nil {
+		t.Fatal("ConfigReceiver() without directory expected error")
+	}
+	if c.Config.Directory != first {
+		t.Fatalf("failed ConfigReceiver() changed the config to %+v", c.Config)
+	}
+	if _, exist, err := c.GetString(ctx, "key"); err != nil || !exist {
+		t.Fatalf("GetString() after failed reconfigure = %v, %v", exist, err)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t, t.TempDir())
+
+	total := 2*sweepBatchSize + 1
+	for i := 0; i < total; i++ {
+		ttl := time.Millisecond
+		if i%2 == 0 {
+			ttl = time.Minute
+		}
+		if err := c.SetInt64(ctx, fmt.Sprintf("key:%d", i), int64(i), ttl); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(2 * time.Millisecond)
+	if err := sweep(c.DB); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	_ = c.DB.View(func(tx *bolt.Tx) error {
+		count = tx.Bucket(bucketName).Stats().KeyN
+		return nil
+	})
+	if want := (total + 1) / 2; count != want {
+		t.Fatalf("%d keys left after sweep, want %d", count, want)
+	}
+}
@@ -0,0 +1,282 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package memcached
+
+import (
+	"context"
+	"crypto/sha1"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/answer-plugins/cache-memcached/i18n"
+	"github.com/apache/answer-plugins/util"
+	"github.com/apache/answer/plugin"
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+const (
+	// maxRelativeExpiration is the max expiration in seconds that memcached treats as relative,
+	// larger values are treated as an absolute unix timestamp
+	maxRelativeExpiration = 30 * 24 * 60 * 60
+	// maxKeyLength is the max key length accepted by memcached
+	maxKeyLength = 250
+	// maxCounterRetries is the number of retries when a missing counter is created concurrently
+	maxCounterRetries = 3
+)
+
+var (
+	configuredErr = fmt.Errorf("memcached is not configured correctly")
+	//go:embed  info.yaml
+	Info embed.FS
+)
+
+type Cache struct {
+	// mu guards the config and the client, the cache methods hold the read lock while they use the client,
+	// so a config change never closes it under a running command
+	mu sync.RWMutex
+
+	Config *CacheConfig
+	Client *memcache.Client
+}
+
+type CacheConfig struct {
+	Servers string `json:"servers"`
+}
+
+func init() {
+	plugin.Register(&Cache{
+		Config: &CacheConfig{},
+	})
+}
+
+func (c *Cache) Info() plugin.Info {
+	info := &util.Info{}
+	info.GetInfo(Info)
+
+	return plugin.Info{
+		Name:        plugin.MakeTranslator(i18n.InfoName),
+		SlugName:    info.SlugName,
+		Description: plugin.MakeTranslator(i18n.InfoDescription),
+		Author:      info.Author,
+		Version:     info.Version,
+		Link:        info.Link,
+	}
+}
+
+func (c *Cache) GetString(ctx context.Context, key string) (data string, exist bool, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.Client == nil {
+		return "", false, configuredErr
+	}
+	item, err := c.Client.Get(c.key(key))
+	if err == memcache.ErrCacheMiss {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return string(item.Value), true, nil
+}
+
+func (c *Cache) SetString(ctx context.Context, key, value string, ttl time.Duration) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.Client == nil {
+		return configuredErr
+	}
+	return c.Client.Set(&memcache.Item{
+		Key:        c.key(key),
+		Value:      []byte(value),
+		Expiration: expiration(ttl),
+	})
+}
+
+func (c *Cache) GetInt64(ctx context.Context, key string) (data int64, exist bool, err error) {
+	value, exist, err := c.GetString(ctx, key)
+	if err != nil || !exist {
+		return 0, false, err
+	}
+	data, err = strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return data, true, nil
+}
+
+func (c *Cache) SetInt64(ctx context.Context, key string, value int64, ttl time.Duration) error {
+	return c.SetString(ctx, key, strconv.FormatInt(value, 10), ttl)
+}
+
+func (c *Cache) Increase(ctx context.Context, key string, value int64) (data int64, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.Client == nil {
+		return 0, configuredErr
+	}
+	return incrBy(c.Client, c.key(key), value)
+}
+
+func (c *Cache) Decrease(ctx context.Context, key string, value int64) (data int64, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.Client == nil {
+		return 0, configuredErr
+	}
+	return incrBy(c.Client, c.key(key), -value)
+}
+
+func (c *Cache) Del(ctx context.Context, key string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.Client == nil {
+		return configuredErr
+	}
+	err := c.Client.Delete(c.key(key))
+	if err == memcache.ErrCacheMiss {
+		return nil
+	}
+	return err
+}
+
+func (c *Cache) Flush(ctx context.Context) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.Client == nil {
+		return configuredErr
+	}
+	return c.Client.DeleteAll()
+}
+
+// incrBy atomically adds delta to the counter. Unlike redis, memcached neither creates missing
+// counters nor stores negative numbers, so a missing counter is created with the delta
+// and counters never go below zero.
+func incrBy(client *memcache.Client, key string, delta int64) (data int64, err error) {
+	for i := 0; i < maxCounterRetries; i++ {
+		var value uint64
+		if delta >= 0 {
+			value, err = client.Increment(key, uint64(delta))
+		} else {
+			value, err = client.Decrement(key, uint64(-delta))
+		}
+		if err == nil {
+			return int64(value), nil
+		}
+		if err != memcache.ErrCacheMiss {
+			return 0, err
+		}
+
+		initial := max(delta, 0)
+		err = client.Add(&memcache.Item{Key: key, Value: []byte(strconv.FormatInt(initial, 10))})
+		if err == nil {
+			return initial, nil
+		}
+		// the counter was created concurrently, increase it again
+		if err != memcache.ErrNotStored {
+			return 0, err
+		}
+	}
+	return 0, err
+}
+
+// key returns a key accepted by memcached. Keys that are too long or contain
+// whitespace or control characters are replaced by their hash.
+func (c *Cache) key(key string) string {
+	if len(key) <= maxKeyLength && !strings.ContainsFunc(key, func(r rune) bool {
+		return r <= ' ' || r == 0x7f
+	}) {
+		return key
+	}
+	sum := sha1.Sum([]byte(key))
+	return "sha1:" + hex.EncodeToString(sum[:])
+}
+
+// expiration converts the ttl to the memcached expiration, 0 means never expire
+func expiration(ttl time.Duration) int32 {
+	if ttl <= 0 {
+		return 0
+	}
+	seconds := int64((ttl + time.Second - 1) / time.Second)
+	if seconds > maxRelativeExpiration {
+		return int32(time.Now().Add(ttl).Unix())
+	}
+	return int32(seconds)
+}
+
+func (c *Cache) ConfigFields() []plugin.ConfigField {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return []plugin.ConfigField{
+		{
+			Name:        "servers",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigServersTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigServersDescription),
+			Required:    true,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.Servers,
+		},
+	}
+}
+
+func (c *Cache) ConfigReceiver(config []byte) error {
+	conf := &CacheConfig{}
+	_ = json.Unmarshal(config, conf)
+
+	var servers []string
+	for _, server := range strings.Split(conf.Servers, ",") {
+		if server = strings.TrimSpace(server); len(server) > 0 {
+			servers = append(servers, server)
+		}
+	}
+	if len(servers) == 0 {
+		return fmt.Errorf("memcached servers are required")
+	}
+	selector := &consistentHashSelector{}
+	if err := selector.SetServers(servers...); err != nil {
+		return fmt.Errorf("resolve memcached servers failed: %w", err)
+	}
+	client := memcache.NewFromSelector(selector)
+	if err := client.Ping(); err != nil {
+		_ = client.Close()
+		return fmt.Errorf("ping memcached failed: %w", err)
+	}
+
+	// the config only takes effect together with the client it was checked with
+	c.mu.Lock()
+	oldClient := c.Client
+	c.Config = conf
+	c.Client = client
+	c.mu.Unlock()
+
+	// no command uses the old client anymore once the write lock was taken
+	if oldClient != nil {
+		_ = oldClient.Close()
+	}
+	return nil
+}
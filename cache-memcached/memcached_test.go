@@ -0,0 +1,165 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package memcached
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestCache connects to the servers in MEMCACHED_SERVERS, e.g. 127.0.0.1:11211,127.0.0.1:11212
+func newTestCache(t *testing.T) *Cache {
+	servers := os.Getenv("MEMCACHED_SERVERS")
+	if servers == "" {
+		t.Skip("MEMCACHED_SERVERS is required")
+	}
+	data, _ := json.Marshal(&CacheConfig{Servers: servers})
+	c := &Cache{Config: &CacheConfig{}}
+	if err := c.ConfigReceiver(data); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = c.Client.Close()
+	})
+	return c
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t)
+
+	longKey := "answer:" + strings.Repeat("k", maxKeyLength)
+	if err := c.SetString(ctx, longKey, "value", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	data, exist, err := c.GetString(ctx, longKey)
+	if err != nil || !exist || data != "value" {
+		t.Fatalf("GetString() = %q, %v, %v", data, exist, err)
+	}
+
+	if n, err := c.Increase(ctx, "answer:test:counter", 2); err != nil || n != 2 {
+		t.Fatalf("Increase() on missing key = %d, %v", n, err)
+	}
+	if n, err := c.Increase(ctx, "answer:test:counter", 3); err != nil || n != 5 {
+		t.Fatalf("Increase() = %d, %v", n, err)
+	}
+	if n, err := c.Decrease(ctx, "answer:test:counter", 1); err != nil || n != 4 {
+		t.Fatalf("Decrease() = %d, %v", n, err)
+	}
+	if n, _, err := c.GetInt64(ctx, "answer:test:counter"); err != nil || n != 4 {
+		t.Fatalf("GetInt64() = %d, %v", n, err)
+	}
+
+	if err := c.Del(ctx, "answer:test:counter"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Del(ctx, "answer:test:counter"); err != nil {
+		t.Fatalf("Del() on missing key = %v", err)
+	}
+	if err := c.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, exist, _ := c.GetString(ctx, longKey); exist {
+		t.Fatal("key still exists after Flush")
+	}
+}
+
+func TestCache_ConfigReceiverFailureKeepsConfig(t *testing.T) {
+	conf := &CacheConfig{Servers: "127.0.0.1:11211"}
+	c := &Cache{Config: conf}
+	for _, next := range []*CacheConfig{
+		{Servers: " , "},
+		// nothing listens on port 1, so the ping fails
+		{Servers: "127.0.0.1:1"},
+	} {
+		data, _ := json.Marshal(next)
+		if err := c.ConfigReceiver(data); err == nil {
+			t.Fatalf("ConfigReceiver(%+v) expected error", next)
+		}
+		if c.Config != conf || c.Client != nil {
+			t.Errorf("ConfigReceiver(%+v) failed but changed the config to %+v", next, c.Config)
+		}
+	}
+}
+
+func TestCache_Reconfigure(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t)
+	data, _ := json.Marshal(c.Config)
+
+	// commands keep working while the client is replaced
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("answer:test:reconfigure:%d", i)
+			for j := 0; j < 50; j++ {
+				if err := c.SetString(ctx, key, "value", time.Minute); err != nil {
+					t.Errorf("SetString() = %v", err)
+					return
+				}
+				if _, err := c.Increase(ctx, key+":counter", 1); err != nil {
+					t.Errorf("Increase() = %v", err)
+					return
+				}
+			}
+		}(i)
+	}
+	for i := 0; i < 5; i++ {
+		if err := c.ConfigReceiver(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+}
+
+func TestConsistentHashSelector(t *testing.T) {
+	servers := []string{"127.0.0.1:11211", "127.0.0.1:11212", "127.0.0.1:11213"}
+	before, after := &consistentHashSelector{}, &consistentHashSelector{}
+	if err := before.SetServers(servers...); err != nil {
+		t.Fatal(err)
+	}
+	if err := after.SetServers(servers[:2]...); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		key := fmt.Sprintf("answer:key:%d", i)
+		from, _ := before.PickServer(key)
+		to, _ := after.PickServer(key)
+		counts[from.String()]++
+		if from.String() != servers[2] && from.String() != to.String() {
+			t.Fatalf("%s moved from %s to %s after removing another server", key, from, to)
+		}
+	}
+	for _, server := range servers {
+		if counts[server] < 500 {
+			t.Errorf("server %s only owns %d of 3000 keys", server, counts[server])
+		}
+	}
+}
@@ -0,0 +1,113 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package memcached
+
+import (
+	"hash/crc32"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+const (
+	// virtualNodes is the number of points each server owns on the hash ring
+	virtualNodes = 160
+)
+
+// consistentHashSelector picks servers from a consistent hash ring,
+// so adding or removing a server only remaps the keys owned by that server.
+type consistentHashSelector struct {
+	mu    sync.RWMutex
+	addrs []net.Addr
+	ring  []ringPoint
+}
+
+type ringPoint struct {
+	hash uint32
+	addr net.Addr
+}
+
+// SetServers resolves the servers and rebuilds the hash ring.
+// If any server fails to resolve, no changes are made.
+func (s *consistentHashSelector) SetServers(servers ...string) error {
+	addrs := make([]net.Addr, 0, len(servers))
+	ring := make([]ringPoint, 0, len(servers)*virtualNodes)
+	for _, server := range servers {
+		addr, err := resolveAddr(server)
+		if err != nil {
+			return err
+		}
+		addrs = append(addrs, addr)
+		for i := 0; i < virtualNodes; i++ {
+			ring = append(ring, ringPoint{
+				hash: crc32.ChecksumIEEE([]byte(server + "-" + strconv.Itoa(i))),
+				addr: addr,
+			})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool {
+		return ring[i].hash < ring[j].hash
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addrs, s.ring = addrs, ring
+	return nil
+}
+
+// PickServer returns the first server clockwise from the hash of the key on the ring
+func (s *consistentHashSelector) PickServer(key string) (net.Addr, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.ring) == 0 {
+		return nil, memcache.ErrNoServers
+	}
+	hash := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(s.ring), func(i int) bool {
+		return s.ring[i].hash >= hash
+	})
+	if i == len(s.ring) {
+		i = 0
+	}
+	return s.ring[i].addr, nil
+}
+
+// Each iterates over each server calling the given function
+func (s *consistentHashSelector) Each(f func(net.Addr) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, addr := range s.addrs {
+		if err := f(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func resolveAddr(server string) (net.Addr, error) {
+	if strings.Contains(server, "/") {
+		return net.ResolveUnixAddr("unix", server)
+	}
+	return net.ResolveTCPAddr("tcp", server)
+}
@@ -0,0 +1,234 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package redis
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	ModeStandalone = "standalone"
+	ModeSentinel   = "sentinel"
+	ModeCluster    = "cluster"
+)
+
+// newRedisClient creates the redis client matching the configured topology.
+// All topologies are exposed as redis.UniversalClient so that the cache methods
+// don't need to know which one is in use.
+// Retries of the redis client are disabled, because they would also replay non-idempotent
+// writes such as INCRBY. Reads are retried by the cache instead.
+func newRedisClient(conf *CacheConfig) (redis.UniversalClient, error) {
+	if isRedisURL(conf.Endpoint) {
+		return newRedisClientFromURL(conf)
+	}
+
+	addrs := splitEndpoints(conf.Endpoint)
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("redis endpoint is required")
+	}
+	db, err := parseDB(conf.DB)
+	if err != nil {
+		return nil, err
+	}
+	var tlsConfig *tls.Config
+	if conf.TLS {
+		tlsConfig, err = newTLSConfig(conf)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch conf.Mode {
+	case "", ModeStandalone:
+		return redis.NewClient(&redis.Options{
+			Addr:         addrs[0],
+			Username:     conf.Username,
+			Password:     conf.Password,
+			DB:           db,
+			TLSConfig:    tlsConfig,
+			MaxRetries:   -1,
+			DialTimeout:  conf.dialTimeout(),
+			ReadTimeout:  conf.readTimeout(),
+			WriteTimeout: conf.writeTimeout(),
+			PoolSize:     conf.poolSize(),
+		}), nil
+	case ModeSentinel:
+		if len(conf.MasterName) == 0 {
+			return nil, fmt.Errorf("redis sentinel master name is required")
+		}
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       conf.MasterName,
+			SentinelAddrs:    addrs,
+			SentinelPassword: conf.SentinelPassword,
+			Username:         conf.Username,
+			Password:         conf.Password,
+			DB:               db,
+			TLSConfig:        tlsConfig,
+			MaxRetries:       -1,
+			DialTimeout:      conf.dialTimeout(),
+			ReadTimeout:      conf.readTimeout(),
+			WriteTimeout:     conf.writeTimeout(),
+			PoolSize:         conf.poolSize(),
+		}), nil
+	case ModeCluster:
+		if db != 0 {
+			return nil, fmt.Errorf("redis cluster only supports database 0")
+		}
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        addrs,
+			Username:     conf.Username,
+			Password:     conf.Password,
+			TLSConfig:    tlsConfig,
+			MaxRetries:   -1,
+			DialTimeout:  conf.dialTimeout(),
+			ReadTimeout:  conf.readTimeout(),
+			WriteTimeout: conf.writeTimeout(),
+			PoolSize:     conf.poolSize(),
+		}), nil
+	default:
+		return nil, fmt.Errorf("unsupported redis mode: %s", conf.Mode)
+	}
+}
+
+// newRedisClientFromURL creates a standalone client from a redis:// or rediss:// URL.
+// Credentials and database in the URL take precedence over the explicit config fields.
+func newRedisClientFromURL(conf *CacheConfig) (redis.UniversalClient, error) {
+	if len(conf.Mode) > 0 && conf.Mode != ModeStandalone {
+		return nil, fmt.Errorf("redis URL is only supported in standalone mode")
+	}
+	opt, err := redis.ParseURL(strings.TrimSpace(conf.Endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("parse redis URL failed: %w", err)
+	}
+	if len(opt.Username) == 0 {
+		opt.Username = conf.Username
+	}
+	if len(opt.Password) == 0 {
+		opt.Password = conf.Password
+	}
+	if opt.DB == 0 {
+		opt.DB, err = parseDB(conf.DB)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if conf.TLS || opt.TLSConfig != nil {
+		tlsConfig, err := newTLSConfig(conf)
+		if err != nil {
+			return nil, err
+		}
+		if opt.TLSConfig != nil {
+			tlsConfig.ServerName = opt.TLSConfig.ServerName
+		}
+		opt.TLSConfig = tlsConfig
+	}
+	opt.MaxRetries = -1
+	if timeout := conf.dialTimeout(); timeout > 0 {
+		opt.DialTimeout = timeout
+	}
+	if timeout := conf.readTimeout(); timeout > 0 {
+		opt.ReadTimeout = timeout
+	}
+	if timeout := conf.writeTimeout(); timeout > 0 {
+		opt.WriteTimeout = timeout
+	}
+	if size := conf.poolSize(); size > 0 {
+		opt.PoolSize = size
+	}
+	return redis.NewClient(opt), nil
+}
+
+// newTLSConfig builds the TLS config from the optional CA bundle and client certificate.
+// The server name is left empty so that it is inferred from the address of each node.
+func newTLSConfig(conf *CacheConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: conf.TLSInsecureSkipVerify,
+	}
+	if len(strings.TrimSpace(conf.TLSCACert)) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(conf.TLSCACert)) {
+			return nil, fmt.Errorf("redis TLS CA certificate is invalid")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	hasCert, hasKey := len(strings.TrimSpace(conf.TLSClientCert)) > 0, len(strings.TrimSpace(conf.TLSClientKey)) > 0
+	if hasCert != hasKey {
+		return nil, fmt.Errorf("redis TLS client certificate and key must be configured together")
+	}
+	if hasCert {
+		cert, err := tls.X509KeyPair([]byte(conf.TLSClientCert), []byte(conf.TLSClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("redis TLS client certificate is invalid: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func (conf *CacheConfig) dialTimeout() time.Duration {
+	return time.Duration(positiveIntOrDefault(conf.DialTimeout, 0)) * time.Millisecond
+}
+
+func (conf *CacheConfig) readTimeout() time.Duration {
+	return time.Duration(positiveIntOrDefault(conf.ReadTimeout, 0)) * time.Millisecond
+}
+
+func (conf *CacheConfig) writeTimeout() time.Duration {
+	return time.Duration(positiveIntOrDefault(conf.WriteTimeout, 0)) * time.Millisecond
+}
+
+func (conf *CacheConfig) poolSize() int {
+	return positiveIntOrDefault(conf.PoolSize, 0)
+}
+
+func isRedisURL(endpoint string) bool {
+	endpoint = strings.TrimSpace(endpoint)
+	return strings.HasPrefix(endpoint, "redis://") || strings.HasPrefix(endpoint, "rediss://")
+}
+
+func parseDB(db string) (int, error) {
+	if len(db) == 0 {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(db)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("redis database index is invalid: %s", db)
+	}
+	return n, nil
+}
+
+// splitEndpoints splits the comma separated endpoint config into addresses
+func splitEndpoints(endpoint string) (addrs []string) {
+	for _, addr := range strings.Split(endpoint, ",") {
+		addr = strings.TrimSpace(addr)
+		if len(addr) > 0 {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
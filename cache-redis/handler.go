@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package redis
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/apache/answer-plugins/cache-redis/i18n"
+	"github.com/apache/answer/plugin"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// RespBody response body.
+type RespBody struct {
+	// http code
+	Code int `json:"code"`
+	// reason key
+	Reason string `json:"reason"`
+	// response message
+	Message string `json:"msg"`
+	// response data
+	Data interface{} `json:"data"`
+}
+
+// NewRespBodyData new response body with data
+func NewRespBodyData(code int, reason string, data interface{}) *RespBody {
+	return &RespBody{
+		Code:   code,
+		Reason: reason,
+		Data:   data,
+	}
+}
+
+func (c *Cache) RegisterUnAuthRouter(r *gin.RouterGroup) {
+	r.GET("/redis-cache/metrics", c.ScrapeMetrics)
+}
+
+func (c *Cache) RegisterAuthUserRouter(r *gin.RouterGroup) {
+}
+
+func (c *Cache) RegisterAuthAdminRouter(r *gin.RouterGroup) {
+	r.GET("/redis-cache/metrics", c.Metrics)
+	r.GET("/redis-cache/status", c.Status)
+}
+
+// Metrics exposes the cache metrics in Prometheus format
+func (c *Cache) Metrics(ctx *gin.Context) {
+	c.mu.RLock()
+	m := c.metrics
+	c.mu.RUnlock()
+	if m == nil {
+		ctx.Status(http.StatusNotFound)
+		return
+	}
+	promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}).ServeHTTP(ctx.Writer, ctx.Request)
+}
+
+// ScrapeMetrics exposes the cache metrics to a Prometheus server without an admin session.
+// It is only enabled when a metrics token is configured, which must be sent as a bearer token.
+func (c *Cache) ScrapeMetrics(ctx *gin.Context) {
+	c.mu.RLock()
+	token := c.Config.MetricsToken
+	c.mu.RUnlock()
+	if len(token) == 0 {
+		ctx.Status(http.StatusNotFound)
+		return
+	}
+	bearer, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+		ctx.Status(http.StatusUnauthorized)
+		return
+	}
+	c.Metrics(ctx)
+}
+
+// Status shows the connected endpoint, server version and memory usage of redis
+func (c *Cache) Status(ctx *gin.Context) {
+	st, err := c.status(ctx.Request.Context())
+	if err != nil {
+		errRespBodyData := NewRespBodyData(http.StatusBadRequest, "error", map[string]any{
+			"err_type": "toast",
+		})
+		errRespBodyData.Message = fmt.Sprintf(plugin.Translate(ctx, i18n.StatusFailedResponse), err)
+		ctx.JSON(http.StatusBadRequest, errRespBodyData)
+		return
+	}
+
+	messages := make([]string, 0, len(st.Nodes))
+	for _, node := range st.Nodes {
+		if len(node.Error) > 0 {
+			messages = append(messages, fmt.Sprintf(plugin.Translate(ctx, i18n.StatusNodeFailedResponse), node.Addr, node.Error))
+			continue
+		}
+		messages = append(messages, fmt.Sprintf(plugin.Translate(ctx, i18n.StatusNodeResponse),
+			node.Addr, node.Version, node.UsedMemory, node.MaxMemory))
+	}
+	ctx.JSON(http.StatusOK, NewRespBodyData(http.StatusOK, "success", map[string]any{
+		"message": strings.Join(messages, "; "),
+		"status":  st,
+	}))
+}
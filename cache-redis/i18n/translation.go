@@ -23,10 +23,63 @@ const (
 	InfoName        = "plugin.redis_cache.backend.info.name"
 	InfoDescription = "plugin.redis_cache.backend.info.description"
 
-	ConfigEndpointTitle       = "plugin.redis_cache.backend.config.endpoint.title"
-	ConfigEndpointDescription = "plugin.redis_cache.backend.config.endpoint.description"
-	ConfigUsernameTitle       = "plugin.redis_cache.backend.config.username.title"
-	ConfigUsernameDescription = "plugin.redis_cache.backend.config.username.description"
-	ConfigPasswordTitle       = "plugin.redis_cache.backend.config.password.title"
-	ConfigPasswordDescription = "plugin.redis_cache.backend.config.password.description"
+	ConfigModeTitle                   = "plugin.redis_cache.backend.config.mode.title"
+	ConfigModeDescription             = "plugin.redis_cache.backend.config.mode.description"
+	ConfigModeOptionsStandalone       = "plugin.redis_cache.backend.config.mode.options.standalone"
+	ConfigModeOptionsSentinel         = "plugin.redis_cache.backend.config.mode.options.sentinel"
+	ConfigModeOptionsCluster          = "plugin.redis_cache.backend.config.mode.options.cluster"
+	ConfigEndpointTitle               = "plugin.redis_cache.backend.config.endpoint.title"
+	ConfigEndpointDescription         = "plugin.redis_cache.backend.config.endpoint.description"
+	ConfigKeyPrefixTitle              = "plugin.redis_cache.backend.config.key_prefix.title"
+	ConfigKeyPrefixDescription        = "plugin.redis_cache.backend.config.key_prefix.description"
+	ConfigMasterNameTitle             = "plugin.redis_cache.backend.config.master_name.title"
+	ConfigMasterNameDescription       = "plugin.redis_cache.backend.config.master_name.description"
+	ConfigUsernameTitle               = "plugin.redis_cache.backend.config.username.title"
+	ConfigUsernameDescription         = "plugin.redis_cache.backend.config.username.description"
+	ConfigPasswordTitle               = "plugin.redis_cache.backend.config.password.title"
+	ConfigPasswordDescription         = "plugin.redis_cache.backend.config.password.description"
+	ConfigSentinelPasswordTitle       = "plugin.redis_cache.backend.config.sentinel_password.title"
+	ConfigSentinelPasswordDescription = "plugin.redis_cache.backend.config.sentinel_password.description"
+	ConfigDBTitle                     = "plugin.redis_cache.backend.config.db.title"
+	ConfigDBDescription               = "plugin.redis_cache.backend.config.db.description"
+	ConfigTLSTitle                    = "plugin.redis_cache.backend.config.tls.title"
+	ConfigTLSLabel                    = "plugin.redis_cache.backend.config.tls.label"
+	ConfigTLSCACertTitle              = "plugin.redis_cache.backend.config.tls_ca_cert.title"
+	ConfigTLSCACertDescription        = "plugin.redis_cache.backend.config.tls_ca_cert.description"
+	ConfigTLSClientCertTitle          = "plugin.redis_cache.backend.config.tls_client_cert.title"
+	ConfigTLSClientCertDescription    = "plugin.redis_cache.backend.config.tls_client_cert.description"
+	ConfigTLSClientKeyTitle           = "plugin.redis_cache.backend.config.tls_client_key.title"
+	ConfigTLSClientKeyDescription     = "plugin.redis_cache.backend.config.tls_client_key.description"
+	ConfigTLSInsecureSkipVerifyTitle  = "plugin.redis_cache.backend.config.tls_insecure_skip_verify.title"
+	ConfigTLSInsecureSkipVerifyLabel  = "plugin.redis_cache.backend.config.tls_insecure_skip_verify.label"
+	ConfigL1CacheTitle                = "plugin.redis_cache.backend.config.l1_cache.title"
+	ConfigL1CacheLabel                = "plugin.redis_cache.backend.config.l1_cache.label"
+	ConfigL1CacheSizeTitle            = "plugin.redis_cache.backend.config.l1_cache_size.title"
+	ConfigL1CacheSizeDescription      = "plugin.redis_cache.backend.config.l1_cache_size.description"
+	ConfigL1CacheTTLTitle             = "plugin.redis_cache.backend.config.l1_cache_ttl.title"
+	ConfigL1CacheTTLDescription       = "plugin.redis_cache.backend.config.l1_cache_ttl.description"
+	ConfigDialTimeoutTitle            = "plugin.redis_cache.backend.config.dial_timeout.title"
+	ConfigDialTimeoutDescription      = "plugin.redis_cache.backend.config.dial_timeout.description"
+	ConfigReadTimeoutTitle            = "plugin.redis_cache.backend.config.read_timeout.title"
+	ConfigReadTimeoutDescription      = "plugin.redis_cache.backend.config.read_timeout.description"
+	ConfigWriteTimeoutTitle           = "plugin.redis_cache.backend.config.write_timeout.title"
+	ConfigWriteTimeoutDescription     = "plugin.redis_cache.backend.config.write_timeout.description"
+	ConfigPoolSizeTitle               = "plugin.redis_cache.backend.config.pool_size.title"
+	ConfigPoolSizeDescription         = "plugin.redis_cache.backend.config.pool_size.description"
+	ConfigReadRetriesTitle            = "plugin.redis_cache.backend.config.read_retries.title"
+	ConfigReadRetriesDescription      = "plugin.redis_cache.backend.config.read_retries.description"
+	ConfigBreakerThresholdTitle       = "plugin.redis_cache.backend.config.breaker_threshold.title"
+	ConfigBreakerThresholdDescription = "plugin.redis_cache.backend.config.breaker_threshold.description"
+	ConfigBreakerCooldownTitle        = "plugin.redis_cache.backend.config.breaker_cooldown.title"
+	ConfigBreakerCooldownDescription  = "plugin.redis_cache.backend.config.breaker_cooldown.description"
+	ConfigMetricsTokenTitle           = "plugin.redis_cache.backend.config.metrics_token.title"
+	ConfigMetricsTokenDescription     = "plugin.redis_cache.backend.config.metrics_token.description"
+	ConfigStatusTitle                 = "plugin.redis_cache.backend.config.status.title"
+	ConfigStatusDescription           = "plugin.redis_cache.backend.config.status.description"
+	ConfigStatusLabel                 = "plugin.redis_cache.backend.config.status.label"
+	ConfigStatusLabelForDoing         = "plugin.redis_cache.backend.config.status.label_for_doing"
+
+	StatusNodeResponse       = "plugin.redis_cache.backend.response.status.node"
+	StatusNodeFailedResponse = "plugin.redis_cache.backend.response.status.node_failed"
+	StatusFailedResponse     = "plugin.redis_cache.backend.response.status.failed"
 )
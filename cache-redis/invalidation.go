@@ -0,0 +1,108 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/segmentfault/pacman/log"
+)
+
+const (
+	// invalidationChannel is the pub/sub channel used to keep the L1 caches of all replicas coherent
+	invalidationChannel = "redis_cache:invalidation"
+)
+
+// invalidation is the message published when a key is changed by one of the replicas
+type invalidation struct {
+	Source string `json:"source"`
+	Key    string `json:"key,omitempty"`
+	Flush  bool   `json:"flush,omitempty"`
+}
+
+// invalidateLocal removes the key from the local cache and notifies the other replicas.
+// A failed publish only leaves the other replicas stale until their L1 entries expire,
+// so it is logged instead of failing the write that already succeeded in redis.
+func (c *Cache) invalidateLocal(ctx context.Context, key string) {
+	if c.local == nil {
+		return
+	}
+	c.local.del(key)
+	c.publishInvalidation(ctx, &invalidation{Source: c.instanceID, Key: key})
+}
+
+// flushLocal clears the local cache and notifies the other replicas
+func (c *Cache) flushLocal(ctx context.Context) {
+	if c.local == nil {
+		return
+	}
+	c.local.flush()
+	c.publishInvalidation(ctx, &invalidation{Source: c.instanceID, Flush: true})
+}
+
+func (c *Cache) publishInvalidation(ctx context.Context, msg *invalidation) {
+	data, _ := json.Marshal(msg)
+	err := c.write("publish", c.key(invalidationChannel), func() error {
+		return c.RedisClient.Publish(ctx, c.key(invalidationChannel), data).Err()
+	})
+	if err != nil {
+		log.Errorf("publish redis cache invalidation failed: %v", err)
+	}
+}
+
+// subscribeInvalidation applies the invalidations published by the other replicas to the local cache
+// until the returned subscription is closed
+func subscribeInvalidation(ctx context.Context, client redis.UniversalClient, channel, instanceID string,
+	local *localCache) (*redis.PubSub, error) {
+	pubsub := client.Subscribe(ctx, channel)
+	// wait for the subscription to be confirmed, otherwise early invalidations may be missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, err
+	}
+	go func() {
+		for message := range pubsub.Channel() {
+			msg := &invalidation{}
+			if err := json.Unmarshal([]byte(message.Payload), msg); err != nil {
+				log.Errorf("parse redis cache invalidation failed: %v", err)
+				continue
+			}
+			if msg.Source == instanceID {
+				continue
+			}
+			if msg.Flush {
+				local.flush()
+			} else {
+				local.del(msg.Key)
+			}
+		}
+	}()
+	return pubsub, nil
+}
+
+func newInstanceID() string {
+	bytes := make([]byte, 8)
+	_, _ = rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
@@ -0,0 +1,69 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package redis
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// flushBatchSize is the COUNT hint of each SCAN and the max number of keys unlinked at once
+	flushBatchSize = 500
+)
+
+var globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+// key returns the key with the configured namespace prefix
+func (c *Cache) key(key string) string {
+	return c.Config.KeyPrefix + key
+}
+
+// unlinkByPrefix removes all keys under the configured prefix from a single redis node.
+// Keys are found by incremental SCAN and removed by UNLINK in batches, so redis is never
+// blocked as it would be by FLUSHDB or KEYS, and keys of other applications are kept.
+func (c *Cache) unlinkByPrefix(ctx context.Context, client redis.Cmdable) error {
+	match := globEscaper.Replace(c.Config.KeyPrefix) + "*"
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(ctx, cursor, match, flushBatchSize).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			// keys in a batch may belong to different cluster slots, so unlink them one by one in a pipeline
+			_, err = client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+				for _, key := range keys {
+					pipe.Unlink(ctx, key)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
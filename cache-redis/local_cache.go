@@ -0,0 +1,118 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package redis
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// localCache is an in-process LRU cache with per-entry expiration, used as the L1 tier in front of redis
+type localCache struct {
+	mu      sync.Mutex
+	size    int
+	items   map[string]*list.Element
+	lru     *list.List
+	version uint64
+}
+
+type localEntry struct {
+	key      string
+	value    string
+	expireAt time.Time
+}
+
+func newLocalCache(size int) *localCache {
+	return &localCache{
+		size:  size,
+		items: make(map[string]*list.Element, size),
+		lru:   list.New(),
+	}
+}
+
+func (l *localCache) get(key string) (value string, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	elem, ok := l.items[key]
+	if !ok {
+		return "", false
+	}
+	entry := elem.Value.(*localEntry)
+	if time.Now().After(entry.expireAt) {
+		l.removeElement(elem)
+		return "", false
+	}
+	l.lru.MoveToFront(elem)
+	return entry.value, true
+}
+
+// currentVersion returns the invalidation version, which should be read before loading a value from redis
+func (l *localCache) currentVersion() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.version
+}
+
+// set stores the value loaded from redis. The value is dropped if any invalidation happened
+// since version was read, because it may have been loaded before a concurrent update.
+func (l *localCache) set(key, value string, ttl time.Duration, version uint64) {
+	if ttl <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.version != version {
+		return
+	}
+	expireAt := time.Now().Add(ttl)
+	if elem, ok := l.items[key]; ok {
+		entry := elem.Value.(*localEntry)
+		entry.value, entry.expireAt = value, expireAt
+		l.lru.MoveToFront(elem)
+		return
+	}
+	l.items[key] = l.lru.PushFront(&localEntry{key: key, value: value, expireAt: expireAt})
+	for l.lru.Len() > l.size {
+		l.removeElement(l.lru.Back())
+	}
+}
+
+func (l *localCache) del(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.version++
+	if elem, ok := l.items[key]; ok {
+		l.removeElement(elem)
+	}
+}
+
+func (l *localCache) flush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.version++
+	l.items = make(map[string]*list.Element, l.size)
+	l.lru.Init()
+}
+
+func (l *localCache) removeElement(elem *list.Element) {
+	l.lru.Remove(elem)
+	delete(l.items, elem.Value.(*localEntry).key)
+}
@@ -0,0 +1,173 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package redis
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "answer_redis_cache"
+
+// cacheMetrics holds the metrics of the cache methods in its own registry,
+// so that they are exposed by the plugin without touching the global registry of the host.
+type cacheMetrics struct {
+	registry  *prometheus.Registry
+	hits      *prometheus.CounterVec
+	misses    *prometheus.CounterVec
+	localHits prometheus.Counter
+	errors    *prometheus.CounterVec
+	skipped   *prometheus.CounterVec
+	duration  *prometheus.HistogramVec
+}
+
+func newCacheMetrics(c *Cache) *cacheMetrics {
+	m := &cacheMetrics{
+		registry: prometheus.NewRegistry(),
+		hits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "hits_total",
+			Help:      "Number of reads that found the key, including local cache hits.",
+		}, []string{"method"}),
+		misses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "misses_total",
+			Help:      "Number of reads that did not find the key.",
+		}, []string{"method"}),
+		localHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "local_hits_total",
+			Help:      "Number of reads served by the local cache without asking redis.",
+		}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "errors_total",
+			Help:      "Number of cache method calls that returned an error.",
+		}, []string{"method"}),
+		skipped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "skipped_total",
+			Help:      "Number of redis commands skipped because the circuit breaker was open.",
+		}, []string{"op"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "duration_seconds",
+			Help:      "Latency of the cache method calls.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"method"}),
+	}
+	m.registry.MustRegister(m.hits, m.misses, m.localHits, m.errors, m.skipped, m.duration, &poolCollector{cache: c})
+	return m
+}
+
+// observe records the latency and the error of a cache method call, it is safe to call on nil metrics
+func (m *cacheMetrics) observe(method string, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	m.duration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.errors.WithLabelValues(method).Inc()
+	}
+}
+
+// observeRead records a read call together with whether the key was found
+func (m *cacheMetrics) observeRead(method string, start time.Time, exist bool, err error) {
+	if m == nil {
+		return
+	}
+	m.observe(method, start, err)
+	if err != nil {
+		return
+	}
+	if exist {
+		m.hits.WithLabelValues(method).Inc()
+	} else {
+		m.misses.WithLabelValues(method).Inc()
+	}
+}
+
+func (m *cacheMetrics) observeLocalHit() {
+	if m == nil {
+		return
+	}
+	m.localHits.Inc()
+}
+
+func (m *cacheMetrics) observeSkipped(op string) {
+	if m == nil {
+		return
+	}
+	m.skipped.WithLabelValues(op).Inc()
+}
+
+var (
+	poolHitsDesc = prometheus.NewDesc(metricsNamespace+"_pool_hits_total",
+		"Number of times a free connection was found in the pool.", nil, nil)
+	poolMissesDesc = prometheus.NewDesc(metricsNamespace+"_pool_misses_total",
+		"Number of times a free connection was not found in the pool.", nil, nil)
+	poolTimeoutsDesc = prometheus.NewDesc(metricsNamespace+"_pool_timeouts_total",
+		"Number of times a wait for a connection timed out.", nil, nil)
+	poolStaleConnsDesc = prometheus.NewDesc(metricsNamespace+"_pool_stale_connections_total",
+		"Number of stale connections removed from the pool.", nil, nil)
+	poolTotalConnsDesc = prometheus.NewDesc(metricsNamespace+"_pool_connections",
+		"Number of connections in the pool.", nil, nil)
+	poolIdleConnsDesc = prometheus.NewDesc(metricsNamespace+"_pool_idle_connections",
+		"Number of idle connections in the pool.", nil, nil)
+	breakerOpenDesc = prometheus.NewDesc(metricsNamespace+"_circuit_breaker_open",
+		"Whether the circuit breaker is open, 1 means redis is considered unhealthy.", nil, nil)
+)
+
+// poolCollector reads the pool statistics of the current redis client on every scrape,
+// so the numbers stay correct after the client is recreated by a config change.
+type poolCollector struct {
+	cache *Cache
+}
+
+func (p *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolHitsDesc
+	ch <- poolMissesDesc
+	ch <- poolTimeoutsDesc
+	ch <- poolStaleConnsDesc
+	ch <- poolTotalConnsDesc
+	ch <- poolIdleConnsDesc
+	ch <- breakerOpenDesc
+}
+
+func (p *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	p.cache.mu.RLock()
+	defer p.cache.mu.RUnlock()
+	if client := p.cache.RedisClient; client != nil {
+		// in cluster mode the statistics are summed over all nodes
+		stats := client.PoolStats()
+		ch <- prometheus.MustNewConstMetric(poolHitsDesc, prometheus.CounterValue, float64(stats.Hits))
+		ch <- prometheus.MustNewConstMetric(poolMissesDesc, prometheus.CounterValue, float64(stats.Misses))
+		ch <- prometheus.MustNewConstMetric(poolTimeoutsDesc, prometheus.CounterValue, float64(stats.Timeouts))
+		ch <- prometheus.MustNewConstMetric(poolStaleConnsDesc, prometheus.CounterValue, float64(stats.StaleConns))
+		ch <- prometheus.MustNewConstMetric(poolTotalConnsDesc, prometheus.GaugeValue, float64(stats.TotalConns))
+		ch <- prometheus.MustNewConstMetric(poolIdleConnsDesc, prometheus.GaugeValue, float64(stats.IdleConns))
+	}
+	var open float64
+	if breaker := p.cache.breaker; breaker != nil && breaker.isOpen() {
+		open = 1
+	}
+	ch <- prometheus.MustNewConstMetric(breakerOpenDesc, prometheus.GaugeValue, open)
+}
@@ -25,6 +25,8 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/apache/answer-plugins/cache-redis/i18n"
@@ -33,6 +35,13 @@ import (
 	"github.com/go-redis/redis/v8"
 )
 
+const (
+	pingTimeout = 5 * time.Second
+
+	defaultL1CacheSize = 10000
+	defaultL1CacheTTL  = time.Minute
+)
+
 var (
 	configuredErr = fmt.Errorf("redis is not configured correctly")
 	//go:embed  info.yaml
@@ -40,14 +49,54 @@ var (
 )
 
 type Cache struct {
+	// mu guards the client and the state built from the config, the cache methods hold the read lock
+	// while they use them, so a config change never closes the client under a running command
+	mu sync.RWMutex
+
 	Config      *CacheConfig
-	RedisClient *redis.Client
+	RedisClient redis.UniversalClient
+
+	local      *localCache
+	localTTL   time.Duration
+	pubsub     *redis.PubSub
+	instanceID string
+
+	breaker     *circuitBreaker
+	readRetries int
+
+	metrics *cacheMetrics
 }
 
 type CacheConfig struct {
-	Endpoint string `json:"endpoint"`
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Mode             string `json:"mode"`
+	KeyPrefix        string `json:"key_prefix"`
+	Endpoint         string `json:"endpoint"`
+	MasterName       string `json:"master_name"`
+	Username         string `json:"username"`
+	Password         string `json:"password"`
+	SentinelPassword string `json:"sentinel_password"`
+	DB               string `json:"db"`
+	TLS              bool   `json:"tls"`
+	TLSCACert        string `json:"tls_ca_cert"`
+	TLSClientCert    string `json:"tls_client_cert"`
+	TLSClientKey     string `json:"tls_client_key"`
+	// TLSInsecureSkipVerify disables the server certificate verification, only for testing
+	TLSInsecureSkipVerify bool `json:"tls_insecure_skip_verify"`
+	// L1Cache enables the in-process cache in front of redis
+	L1Cache     bool   `json:"l1_cache"`
+	L1CacheSize string `json:"l1_cache_size"`
+	L1CacheTTL  string `json:"l1_cache_ttl"`
+	// timeouts in milliseconds, empty means the redis client default
+	DialTimeout  string `json:"dial_timeout"`
+	ReadTimeout  string `json:"read_timeout"`
+	WriteTimeout string `json:"write_timeout"`
+	PoolSize     string `json:"pool_size"`
+	ReadRetries  string `json:"read_retries"`
+	// BreakerThreshold is the number of consecutive failures that opens the circuit breaker
+	BreakerThreshold string `json:"breaker_threshold"`
+	BreakerCooldown  string `json:"breaker_cooldown"`
+	// MetricsToken enables the metrics endpoint for Prometheus without an admin session
+	MetricsToken string `json:"metrics_token"`
 }
 
 func init() {
@@ -71,77 +120,226 @@ func (c *Cache) Info() plugin.Info {
 }
 
 func (c *Cache) GetString(ctx context.Context, key string) (data string, exist bool, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	defer func(start time.Time) { c.metrics.observeRead("get_string", start, exist, err) }(time.Now())
 	if c.RedisClient == nil {
 		return "", false, configuredErr
 	}
-	data, err = c.RedisClient.Get(ctx, key).Result()
-	if err == redis.Nil {
-		return "", false, nil
-	}
-	if err != nil {
-		return "", false, err
-	}
-	return data, true, nil
+	return c.get(ctx, c.key(key))
 }
 
-func (c *Cache) SetString(ctx context.Context, key, value string, ttl time.Duration) error {
+func (c *Cache) SetString(ctx context.Context, key, value string, ttl time.Duration) (err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	defer func(start time.Time) { c.metrics.observe("set_string", start, err) }(time.Now())
 	if c.RedisClient == nil {
 		return configuredErr
 	}
-	return c.RedisClient.Set(ctx, key, value, ttl).Err()
+	err = c.write("set", key, func() error {
+		return c.RedisClient.Set(ctx, c.key(key), value, ttl).Err()
+	})
+	if err != nil {
+		return err
+	}
+	c.invalidateLocal(ctx, c.key(key))
+	return nil
 }
 
 func (c *Cache) GetInt64(ctx context.Context, key string) (data int64, exist bool, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	defer func(start time.Time) { c.metrics.observeRead("get_int64", start, exist, err) }(time.Now())
 	if c.RedisClient == nil {
 		return 0, false, configuredErr
 	}
-	data, err = c.RedisClient.Get(ctx, key).Int64()
-	if err == redis.Nil {
-		return 0, false, nil
+	value, exist, err := c.get(ctx, c.key(key))
+	if err != nil || !exist {
+		return 0, false, err
 	}
+	data, err = strconv.ParseInt(value, 10, 64)
 	if err != nil {
 		return 0, false, err
 	}
 	return data, true, nil
 }
 
-func (c *Cache) SetInt64(ctx context.Context, key string, value int64, ttl time.Duration) error {
+func (c *Cache) SetInt64(ctx context.Context, key string, value int64, ttl time.Duration) (err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	defer func(start time.Time) { c.metrics.observe("set_int64", start, err) }(time.Now())
 	if c.RedisClient == nil {
 		return configuredErr
 	}
-	return c.RedisClient.Set(ctx, key, value, ttl).Err()
+	err = c.write("set", key, func() error {
+		return c.RedisClient.Set(ctx, c.key(key), value, ttl).Err()
+	})
+	if err != nil {
+		return err
+	}
+	c.invalidateLocal(ctx, c.key(key))
+	return nil
 }
 
 func (c *Cache) Increase(ctx context.Context, key string, value int64) (data int64, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	defer func(start time.Time) { c.metrics.observe("increase", start, err) }(time.Now())
 	if c.RedisClient == nil {
 		return 0, configuredErr
 	}
-	return c.RedisClient.IncrBy(ctx, key, value).Result()
+	// unlike the other writes a counter fails while the circuit breaker is open,
+	// the caller would take a skipped one for the real value of the counter
+	err = c.call(func() (err error) {
+		data, err = c.RedisClient.IncrBy(ctx, c.key(key), value).Result()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	c.invalidateLocal(ctx, c.key(key))
+	return data, nil
 }
 
 func (c *Cache) Decrease(ctx context.Context, key string, value int64) (data int64, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	defer func(start time.Time) { c.metrics.observe("decrease", start, err) }(time.Now())
 	if c.RedisClient == nil {
 		return 0, configuredErr
 	}
-	return c.RedisClient.DecrBy(ctx, key, value).Result()
+	// unlike the other writes a counter fails while the circuit breaker is open,
+	// the caller would take a skipped one for the real value of the counter
+	err = c.call(func() (err error) {
+		data, err = c.RedisClient.DecrBy(ctx, c.key(key), value).Result()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	c.invalidateLocal(ctx, c.key(key))
+	return data, nil
 }
 
-func (c *Cache) Del(ctx context.Context, key string) error {
+func (c *Cache) Del(ctx context.Context, key string) (err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	defer func(start time.Time) { c.metrics.observe("del", start, err) }(time.Now())
 	if c.RedisClient == nil {
 		return configuredErr
 	}
-	return c.RedisClient.Del(ctx, key).Err()
+	err = c.write("del", key, func() error {
+		return c.RedisClient.Del(ctx, c.key(key)).Err()
+	})
+	if err != nil {
+		return err
+	}
+	c.invalidateLocal(ctx, c.key(key))
+	return nil
 }
 
-func (c *Cache) Flush(ctx context.Context) error {
+func (c *Cache) Flush(ctx context.Context) (err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	defer func(start time.Time) { c.metrics.observe("flush", start, err) }(time.Now())
 	if c.RedisClient == nil {
 		return configuredErr
 	}
-	return c.RedisClient.FlushDB(ctx).Err()
+	err = c.write("flush", c.Config.KeyPrefix+"*", func() error {
+		if cluster, ok := c.RedisClient.(*redis.ClusterClient); ok {
+			return cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+				return c.unlinkByPrefix(ctx, client)
+			})
+		}
+		return c.unlinkByPrefix(ctx, c.RedisClient)
+	})
+	// keys may have been removed even if the flush failed halfway
+	c.flushLocal(ctx)
+	return err
+}
+
+// get reads the value from the local cache first if enabled, otherwise from redis.
+// Values read from redis are kept locally no longer than their remaining TTL in redis.
+// While the circuit breaker is open, reads from redis are treated as cache misses.
+func (c *Cache) get(ctx context.Context, key string) (data string, exist bool, err error) {
+	if c.local == nil {
+		err = c.read(ctx, func() (err error) {
+			data, err = c.RedisClient.Get(ctx, key).Result()
+			return err
+		})
+		if err == errCircuitOpen {
+			c.metrics.observeSkipped("get")
+		}
+		if err == redis.Nil || err == errCircuitOpen {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		return data, true, nil
+	}
+
+	if data, exist = c.local.get(key); exist {
+		c.metrics.observeLocalHit()
+		return data, true, nil
+	}
+	version := c.local.currentVersion()
+	var (
+		getCmd *redis.StringCmd
+		ttlCmd *redis.DurationCmd
+	)
+	err = c.read(ctx, func() (err error) {
+		_, err = c.RedisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			getCmd = pipe.Get(ctx, key)
+			ttlCmd = pipe.PTTL(ctx, key)
+			return nil
+		})
+		return err
+	})
+	if err == errCircuitOpen {
+		c.metrics.observeSkipped("get")
+	}
+	if err == redis.Nil || err == errCircuitOpen {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	data = getCmd.Val()
+
+	ttl := c.localTTL
+	// a negative TTL means the key has no expiration
+	if remain := ttlCmd.Val(); remain >= 0 && remain < ttl {
+		ttl = remain
+	}
+	c.local.set(key, data, ttl, version)
+	return data, true, nil
 }
 
 func (c *Cache) ConfigFields() []plugin.ConfigField {
 	return []plugin.ConfigField{
+		{
+			Name:        "mode",
+			Type:        plugin.ConfigTypeSelect,
+			Title:       plugin.MakeTranslator(i18n.ConfigModeTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigModeDescription),
+			Required:    false,
+			Options: []plugin.ConfigFieldOption{
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigModeOptionsStandalone),
+					Value: ModeStandalone,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigModeOptionsSentinel),
+					Value: ModeSentinel,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigModeOptionsCluster),
+					Value: ModeCluster,
+				},
+			},
+			Value: c.Config.Mode,
+		},
 		{
 			Name:        "endpoint",
 			Type:        plugin.ConfigTypeInput,
@@ -153,6 +351,28 @@ func (c *Cache) ConfigFields() []plugin.ConfigField {
 			},
 			Value: c.Config.Endpoint,
 		},
+		{
+			Name:        "key_prefix",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigKeyPrefixTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigKeyPrefixDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.KeyPrefix,
+		},
+		{
+			Name:        "master_name",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigMasterNameTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigMasterNameDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.MasterName,
+		},
 		{
 			Name:        "username",
 			Type:        plugin.ConfigTypeInput,
@@ -175,18 +395,284 @@ func (c *Cache) ConfigFields() []plugin.ConfigField {
 			},
 			Value: c.Config.Password,
 		},
+		{
+			Name:        "sentinel_password",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigSentinelPasswordTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigSentinelPasswordDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypePassword,
+			},
+			Value: c.Config.SentinelPassword,
+		},
+		{
+			Name:        "db",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigDBTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigDBDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.DB,
+		},
+		{
+			Name:  "tls",
+			Type:  plugin.ConfigTypeSwitch,
+			Title: plugin.MakeTranslator(i18n.ConfigTLSTitle),
+			Value: c.Config.TLS,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				Label: plugin.MakeTranslator(i18n.ConfigTLSLabel),
+			},
+		},
+		{
+			Name:        "tls_ca_cert",
+			Type:        plugin.ConfigTypeTextarea,
+			Title:       plugin.MakeTranslator(i18n.ConfigTLSCACertTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigTLSCACertDescription),
+			Required:    false,
+			Value:       c.Config.TLSCACert,
+		},
+		{
+			Name:        "tls_client_cert",
+			Type:        plugin.ConfigTypeTextarea,
+			Title:       plugin.MakeTranslator(i18n.ConfigTLSClientCertTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigTLSClientCertDescription),
+			Required:    false,
+			Value:       c.Config.TLSClientCert,
+		},
+		{
+			Name:        "tls_client_key",
+			Type:        plugin.ConfigTypeTextarea,
+			Title:       plugin.MakeTranslator(i18n.ConfigTLSClientKeyTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigTLSClientKeyDescription),
+			Required:    false,
+			Value:       c.Config.TLSClientKey,
+		},
+		{
+			Name:  "tls_insecure_skip_verify",
+			Type:  plugin.ConfigTypeSwitch,
+			Title: plugin.MakeTranslator(i18n.ConfigTLSInsecureSkipVerifyTitle),
+			Value: c.Config.TLSInsecureSkipVerify,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				Label: plugin.MakeTranslator(i18n.ConfigTLSInsecureSkipVerifyLabel),
+			},
+		},
+		{
+			Name:  "l1_cache",
+			Type:  plugin.ConfigTypeSwitch,
+			Title: plugin.MakeTranslator(i18n.ConfigL1CacheTitle),
+			Value: c.Config.L1Cache,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				Label: plugin.MakeTranslator(i18n.ConfigL1CacheLabel),
+			},
+		},
+		{
+			Name:        "l1_cache_size",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigL1CacheSizeTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigL1CacheSizeDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.L1CacheSize,
+		},
+		{
+			Name:        "l1_cache_ttl",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigL1CacheTTLTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigL1CacheTTLDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.L1CacheTTL,
+		},
+		{
+			Name:        "dial_timeout",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigDialTimeoutTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigDialTimeoutDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.DialTimeout,
+		},
+		{
+			Name:        "read_timeout",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigReadTimeoutTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigReadTimeoutDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.ReadTimeout,
+		},
+		{
+			Name:        "write_timeout",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigWriteTimeoutTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigWriteTimeoutDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.WriteTimeout,
+		},
+		{
+			Name:        "pool_size",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigPoolSizeTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigPoolSizeDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.PoolSize,
+		},
+		{
+			Name:        "read_retries",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigReadRetriesTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigReadRetriesDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.ReadRetries,
+		},
+		{
+			Name:        "breaker_threshold",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigBreakerThresholdTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigBreakerThresholdDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.BreakerThreshold,
+		},
+		{
+			Name:        "breaker_cooldown",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigBreakerCooldownTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigBreakerCooldownDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.BreakerCooldown,
+		},
+		{
+			Name:        "metrics_token",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigMetricsTokenTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigMetricsTokenDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypePassword,
+			},
+			Value: c.Config.MetricsToken,
+		},
+		{
+			Name:        "status",
+			Type:        plugin.ConfigTypeButton,
+			Title:       plugin.MakeTranslator(i18n.ConfigStatusTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigStatusDescription),
+			UIOptions: plugin.ConfigFieldUIOptions{
+				Text: plugin.MakeTranslator(i18n.ConfigStatusLabel),
+				Action: &plugin.UIOptionAction{
+					Url:    "/answer/admin/api/redis-cache/status",
+					Method: "get",
+					Loading: &plugin.LoadingAction{
+						Text:  plugin.MakeTranslator(i18n.ConfigStatusLabelForDoing),
+						State: plugin.LoadingActionStateNone,
+					},
+					OnComplete: &plugin.OnCompleteAction{
+						ToastReturnMessage: true,
+					},
+				},
+				Variant: "outline-secondary",
+			},
+		},
 	}
 }
 
 func (c *Cache) ConfigReceiver(config []byte) error {
 	conf := &CacheConfig{}
 	_ = json.Unmarshal(config, conf)
+
+	client, err := newRedisClient(conf)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	if err = client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return fmt.Errorf("ping redis failed: %w", err)
+	}
+	var (
+		local      *localCache
+		pubsub     *redis.PubSub
+		instanceID = newInstanceID()
+	)
+	if conf.L1Cache {
+		local = newLocalCache(conf.l1CacheSize())
+		pubsub, err = subscribeInvalidation(ctx, client, conf.KeyPrefix+invalidationChannel, instanceID, local)
+		if err != nil {
+			_ = client.Close()
+			return fmt.Errorf("subscribe redis cache invalidation failed: %w", err)
+		}
+	}
+	readRetries := defaultReadRetries
+	if retries, err := strconv.Atoi(conf.ReadRetries); err == nil && retries >= 0 {
+		readRetries = retries
+	}
+	breaker := newCircuitBreaker(
+		positiveIntOrDefault(conf.BreakerThreshold, defaultBreakerThreshold),
+		time.Duration(positiveIntOrDefault(conf.BreakerCooldown, int(defaultBreakerCooldown/time.Second)))*time.Second,
+	)
+
+	// the config only takes effect together with the client it was checked with
+	c.mu.Lock()
+	oldClient, oldPubsub := c.RedisClient, c.pubsub
 	c.Config = conf
+	c.RedisClient = client
+	c.local, c.localTTL, c.pubsub, c.instanceID = local, conf.l1CacheTTL(), pubsub, instanceID
+	c.breaker, c.readRetries = breaker, readRetries
+	if c.metrics == nil {
+		c.metrics = newCacheMetrics(c)
+	}
+	c.mu.Unlock()
 
-	c.RedisClient = redis.NewClient(&redis.Options{
-		Addr:     conf.Endpoint,
-		Username: conf.Username,
-		Password: conf.Password,
-	})
+	// no command uses the old client anymore once the write lock was taken
+	if oldPubsub != nil {
+		_ = oldPubsub.Close()
+	}
+	if oldClient != nil {
+		_ = oldClient.Close()
+	}
 	return nil
 }
+
+func (conf *CacheConfig) l1CacheSize() int {
+	return positiveIntOrDefault(conf.L1CacheSize, defaultL1CacheSize)
+}
+
+func (conf *CacheConfig) l1CacheTTL() time.Duration {
+	return time.Duration(positiveIntOrDefault(conf.L1CacheTTL, int(defaultL1CacheTTL/time.Second))) * time.Second
+}
+
+// positiveIntOrDefault parses the numeric config value, empty or invalid values fall back to the default
+func positiveIntOrDefault(value string, defaultValue int) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return defaultValue
+	}
+	return n
+}
@@ -0,0 +1,154 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/segmentfault/pacman/log"
+)
+
+const (
+	defaultReadRetries      = 2
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 10 * time.Second
+	retryBaseBackoff        = 10 * time.Millisecond
+)
+
+var errCircuitOpen = errors.New("redis circuit breaker is open")
+
+// circuitBreaker stops sending commands to redis after consecutive failures.
+// It opens for the cooldown, then lets a single probe through and closes again once a probe succeeds.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a command may be sent to redis
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.probing || time.Now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// report records the result of a command that was allowed
+func (b *circuitBreaker) report(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// a canceled request says nothing about the health of redis
+	if errors.Is(err, context.Canceled) {
+		b.probing = false
+		return
+	}
+	if !isFailure(err) {
+		if b.failures >= b.threshold {
+			log.Info("redis circuit breaker closed, redis is healthy again")
+		}
+		b.failures, b.probing = 0, false
+		return
+	}
+	b.failures++
+	b.probing = false
+	if b.failures >= b.threshold {
+		if b.failures == b.threshold {
+			log.Warnf("redis circuit breaker opened after %d consecutive failures, last error: %v", b.failures, err)
+		}
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// isOpen reports whether redis is currently considered unhealthy
+func (b *circuitBreaker) isOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.threshold
+}
+
+// isFailure reports whether the error means redis is unhealthy. Missing keys and errors replied
+// by redis itself, such as WRONGTYPE, mean redis is working.
+func isFailure(err error) bool {
+	if err == nil || err == redis.Nil {
+		return false
+	}
+	var redisErr redis.Error
+	return !errors.As(err, &redisErr)
+}
+
+// call runs the redis command through the circuit breaker
+func (c *Cache) call(fn func() error) error {
+	if c.breaker == nil {
+		return fn()
+	}
+	if !c.breaker.allow() {
+		return errCircuitOpen
+	}
+	err := fn()
+	c.breaker.report(err)
+	return err
+}
+
+// read runs an idempotent redis read, retrying failures with jittered exponential backoff
+func (c *Cache) read(ctx context.Context, fn func() error) (err error) {
+	for attempt := 0; ; attempt++ {
+		err = c.call(fn)
+		if !isFailure(err) || err == errCircuitOpen || attempt >= c.readRetries {
+			return err
+		}
+		backoff := retryBaseBackoff << attempt
+		backoff = backoff/2 + time.Duration(rand.Int63n(int64(backoff)))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// write runs a redis write with no result, such as set and del. While the circuit breaker is open
+// the write is skipped, so the site keeps working without the cache instead of failing.
+func (c *Cache) write(op, key string, fn func() error) error {
+	err := c.call(fn)
+	if err == errCircuitOpen {
+		log.Debugf("redis circuit breaker is open, skip %s %s", op, key)
+		c.metrics.observeSkipped(op)
+		return nil
+	}
+	return err
+}
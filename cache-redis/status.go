@@ -0,0 +1,137 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package redis
+
+import (
+	"bufio"
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// cacheStatus is the connection status shown to the admin
+type cacheStatus struct {
+	Mode               string           `json:"mode"`
+	Endpoint           string           `json:"endpoint"`
+	Nodes              []*nodeStatus    `json:"nodes"`
+	Pool               *redis.PoolStats `json:"pool"`
+	CircuitBreakerOpen bool             `json:"circuit_breaker_open"`
+}
+
+type nodeStatus struct {
+	Addr       string `json:"addr"`
+	Version    string `json:"version,omitempty"`
+	UsedMemory string `json:"used_memory,omitempty"`
+	MaxMemory  string `json:"max_memory,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// status reads the server version and memory usage from INFO of every master node
+func (c *Cache) status(ctx context.Context) (*cacheStatus, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	client := c.RedisClient
+	if client == nil {
+		return nil, configuredErr
+	}
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	st := &cacheStatus{
+		Mode:     c.Config.Mode,
+		Endpoint: redactEndpoint(c.Config.Endpoint),
+		Pool:     client.PoolStats(),
+	}
+	if len(st.Mode) == 0 {
+		st.Mode = ModeStandalone
+	}
+	if c.breaker != nil {
+		st.CircuitBreakerOpen = c.breaker.isOpen()
+	}
+
+	switch client := client.(type) {
+	case *redis.ClusterClient:
+		var mu sync.Mutex
+		err := client.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			ns := readNodeStatus(ctx, node, node.Options().Addr)
+			mu.Lock()
+			st.Nodes = append(st.Nodes, ns)
+			mu.Unlock()
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	case *redis.Client:
+		addr := client.Options().Addr
+		// the failover client has no fixed address, the master is found through the sentinels
+		if st.Mode == ModeSentinel {
+			addr = c.Config.MasterName
+		}
+		st.Nodes = append(st.Nodes, readNodeStatus(ctx, client, addr))
+	}
+	return st, nil
+}
+
+func readNodeStatus(ctx context.Context, client *redis.Client, addr string) *nodeStatus {
+	ns := &nodeStatus{Addr: addr}
+	info, err := client.Info(ctx).Result()
+	if err != nil {
+		ns.Error = err.Error()
+		return ns
+	}
+	fields := parseInfo(info)
+	ns.Version = fields["redis_version"]
+	ns.UsedMemory = fields["used_memory_human"]
+	ns.MaxMemory = fields["maxmemory_human"]
+	return ns
+}
+
+// parseInfo parses the "field:value" lines of the INFO reply, section headers are skipped
+func parseInfo(info string) map[string]string {
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(info))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if field, value, ok := strings.Cut(line, ":"); ok {
+			fields[field] = value
+		}
+	}
+	return fields
+}
+
+// redactEndpoint removes the credentials from a redis URL endpoint
+func redactEndpoint(endpoint string) string {
+	if !isRedisURL(endpoint) {
+		return endpoint
+	}
+	u, err := url.Parse(strings.TrimSpace(endpoint))
+	if err != nil {
+		return ""
+	}
+	u.User = nil
+	return u.String()
+}
@@ -17,6 +17,74 @@
  * under the License.
  */
 
+import { useEffect, useRef } from 'react';
+import { Button } from 'react-bootstrap';
+
+export interface CaptchaMedia {
+  image: string;
+  audio: string;
+}
+
+/**
+ * When the audio captcha is enabled, `captcha_img` is a JSON object with the image
+ * and the spoken digits, otherwise it is the image itself.
+ */
+export const parseCaptchaMedia = (captchaImg = ''): CaptchaMedia => {
+  if (captchaImg.startsWith('{')) {
+    try {
+      const media = JSON.parse(captchaImg);
+      return {
+        image: media.image || '',
+        audio: media.audio || '',
+      };
+    } catch (e) {
+      console.log('parseCaptchaMedia', e);
+    }
+  }
+  return { image: captchaImg, audio: '' };
+};
+
+export interface ListenButtonProps {
+  src: string;
+  title: string;
+}
+
+export const ListenButton = ({ src, title }: ListenButtonProps) => {
+  const refAudio = useRef<HTMLAudioElement | null>(null);
+
+  useEffect(() => {
+    return () => {
+      refAudio.current?.pause();
+    };
+  }, [src]);
+
+  const handleListen = () => {
+    refAudio.current?.pause();
+    refAudio.current = new Audio(src);
+    refAudio.current.play();
+  };
+
+  return (
+    <Button
+      onClick={handleListen}
+      variant="outline-secondary"
+      title={title}
+      aria-label={title}>
+      <svg
+        xmlns="http://www.w3.org/2000/svg"
+        width="16"
+        height="16"
+        fill="currentColor"
+        className="bi bi-volume-up"
+        viewBox="0 0 16 16">
+        <path d="M11.536 14.01A8.47 8.47 0 0 0 14.026 8a8.47 8.47 0 0 0-2.49-6.01l-.708.707A7.48 7.48 0 0 1 13.025 8c0 2.071-.84 3.946-2.197 5.303z" />
+        <path d="M10.121 12.596A6.48 6.48 0 0 0 12.025 8a6.48 6.48 0 0 0-1.904-4.596l-.707.707A5.48 5.48 0 0 1 11.025 8a5.48 5.48 0 0 1-1.61 3.89z" />
+        <path d="M10.025 8a4.5 4.5 0 0 1-1.318 3.182L8 10.475A3.5 3.5 0 0 0 9.025 8c0-.966-.392-1.841-1.025-2.475l.707-.707A4.5 4.5 0 0 1 10.025 8M7 4a.5.5 0 0 0-.812-.39L3.825 5.5H1.5A.5.5 0 0 0 1 6v4a.5.5 0 0 0 .5.5h2.325l2.363 1.89A.5.5 0 0 0 7 12zM4.312 6.39 6 5.04v5.92L4.312 9.61A.5.5 0 0 0 4 9.5H2v-3h2a.5.5 0 0 0 .312-.11" />
+      </svg>
+    </Button>
+  );
+};
+
 const Index = () => {
   return (
     <div>captcha basic</div>
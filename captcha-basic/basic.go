@@ -21,22 +21,69 @@ package basic
 
 import (
 	"embed"
-	"github.com/apache/answer-plugins/util"
-	"image/color"
+	"encoding/json"
 
 	"github.com/apache/answer-plugins/captcha-basic/i18n"
+	"github.com/apache/answer-plugins/util"
 	"github.com/apache/answer/plugin"
-	"github.com/mojocn/base64Captcha"
+	"github.com/segmentfault/pacman/log"
 )
 
 //go:embed  info.yaml
 var Info embed.FS
 
 type Captcha struct {
+	Config   *CaptchaConfig
+	attempts *attemptCounter
+}
+
+type CaptchaConfig struct {
+	Mode   string `json:"mode"`
+	Length string `json:"length"`
+	Width  string `json:"width"`
+	Height string `json:"height"`
+	// NoiseCount is the number of noise characters, or the number of dots in digit mode
+	NoiseCount string `json:"noise_count"`
+	HollowLine bool   `json:"hollow_line"`
+	SlimeLine  bool   `json:"slime_line"`
+	SineLine   bool   `json:"sine_line"`
+	Source     string `json:"source"`
+	// ExcludeAmbiguous removes characters that are easily confused, such as 0/O and 1/l, from the source
+	ExcludeAmbiguous bool   `json:"exclude_ambiguous"`
+	Font             string `json:"font"`
+	// Normalize ignores spaces, letter case and full-width characters when verifying
+	Normalize bool `json:"normalize"`
+	// MaxAttempts is the number of guesses allowed for one challenge
+	MaxAttempts string `json:"max_attempts"`
+	// Audio offers spoken digits as an alternative to the image
+	Audio         bool   `json:"audio"`
+	AudioLanguage string `json:"audio_language"`
+}
+
+// challengeMedia is returned as the captcha when the audio challenge is enabled,
+// the frontend shows the image and plays the audio on demand
+type challengeMedia struct {
+	Image string `json:"image"`
+	Audio string `json:"audio"`
 }
 
 func init() {
-	plugin.Register(&Captcha{})
+	plugin.Register(&Captcha{
+		Config:   defaultCaptchaConfig(),
+		attempts: newAttemptCounter(),
+	})
+}
+
+// defaultCaptchaConfig keeps the captcha of the previous versions for sites that are not configured
+func defaultCaptchaConfig() *CaptchaConfig {
+	return &CaptchaConfig{
+		Mode:          ModeString,
+		HollowLine:    true,
+		SlimeLine:     true,
+		Font:          defaultFont,
+		Normalize:     true,
+		AudioLanguage: defaultAudioLanguage,
+	}
 }
 
 func (c *Captcha) Info() plugin.Info {
@@ -58,25 +105,276 @@ func (c *Captcha) GetConfig() (config string) {
 }
 
 func (c *Captcha) Create() (captcha, code string) {
-	driverString := base64Captcha.DriverString{
-		Height:          60,
-		Width:           200,
-		NoiseCount:      0,
-		ShowLineOptions: 2 | 4,
-		Length:          4,
-		Source:          "1234567890qwertyuioplkjhgfdsazxcvbnm",
-		BgColor:         &color.RGBA{R: 211, G: 211, B: 211, A: 0},
-		Fonts:           []string{"wqy-microhei.ttc"},
+	driver := newDriver(c.Config)
+	_, content, answer := driver.GenerateIdQuestionAnswer()
+	item, err := driver.DrawCaptcha(content)
+	if err != nil {
+		log.Errorf("draw captcha failed: %v", err)
+		return "", ""
+	}
+	if !c.Config.Audio {
+		return item.EncodeB64string(), newChallengeCode(answer, "")
+	}
+
+	audioDriver := newAudioDriver(c.Config)
+	_, audioContent, audioAnswer := audioDriver.GenerateIdQuestionAnswer()
+	audio, err := audioDriver.DrawCaptcha(audioContent)
+	if err != nil {
+		log.Errorf("draw audio captcha failed: %v", err)
+		return item.EncodeB64string(), newChallengeCode(answer, "")
 	}
-	driver := driverString.ConvertFonts()
-	_, content, answer := driverString.ConvertFonts().GenerateIdQuestionAnswer()
-	item, _ := driver.DrawCaptcha(content)
-	return item.EncodeB64string(), answer
+	data, _ := json.Marshal(&challengeMedia{
+		Image: item.EncodeB64string(),
+		Audio: audio.EncodeB64string(),
+	})
+	return string(data), newChallengeCode(answer, audioAnswer)
 }
 
 func (c *Captcha) Verify(captcha, userInput string) (pass bool) {
 	if len(captcha) == 0 || len(userInput) == 0 {
 		return false
 	}
-	return captcha == userInput
+	id, answer, audioAnswer := parseChallengeCode(captcha)
+	if len(id) > 0 && c.attempts != nil {
+		if !c.attempts.add(id, positiveIntOrDefault(c.Config.MaxAttempts, defaultMaxAttempts)) {
+			log.Debugf("captcha %s exceeded the max attempts", id)
+			return false
+		}
+	}
+	if c.Config.Normalize {
+		answer, audioAnswer, userInput = normalize(answer), normalize(audioAnswer), normalize(userInput)
+	}
+	if equalAnswer(answer, userInput) {
+		return true
+	}
+	// either the image or the audio challenge may be solved
+	return len(audioAnswer) > 0 && equalAnswer(audioAnswer, userInput)
+}
+
+func (c *Captcha) ConfigFields() []plugin.ConfigField {
+	fontOptions := []plugin.ConfigFieldOption{
+		{
+			Label: plugin.MakeTranslator(i18n.ConfigFontOptionsRandom),
+			Value: fontRandom,
+		},
+	}
+	for _, font := range fonts {
+		fontOptions = append(fontOptions, plugin.ConfigFieldOption{
+			Label: literal(font),
+			Value: font,
+		})
+	}
+
+	return []plugin.ConfigField{
+		{
+			Name:        "mode",
+			Type:        plugin.ConfigTypeSelect,
+			Title:       plugin.MakeTranslator(i18n.ConfigModeTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigModeDescription),
+			Required:    false,
+			Options: []plugin.ConfigFieldOption{
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigModeOptionsString),
+					Value: ModeString,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigModeOptionsMath),
+					Value: ModeMath,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigModeOptionsDigit),
+					Value: ModeDigit,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigModeOptionsChinese),
+					Value: ModeChinese,
+				},
+			},
+			Value: c.Config.Mode,
+		},
+		{
+			Name:        "length",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigLengthTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigLengthDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.Length,
+		},
+		{
+			Name:        "width",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigWidthTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigWidthDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.Width,
+		},
+		{
+			Name:        "height",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigHeightTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigHeightDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.Height,
+		},
+		{
+			Name:        "noise_count",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigNoiseCountTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigNoiseCountDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.NoiseCount,
+		},
+		{
+			Name:     "hollow_line",
+			Type:     plugin.ConfigTypeSwitch,
+			Title:    plugin.MakeTranslator(i18n.ConfigHollowLineTitle),
+			Required: false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				Label: plugin.MakeTranslator(i18n.ConfigHollowLineLabel),
+			},
+			Value: c.Config.HollowLine,
+		},
+		{
+			Name:     "slime_line",
+			Type:     plugin.ConfigTypeSwitch,
+			Title:    plugin.MakeTranslator(i18n.ConfigSlimeLineTitle),
+			Required: false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				Label: plugin.MakeTranslator(i18n.ConfigSlimeLineLabel),
+			},
+			Value: c.Config.SlimeLine,
+		},
+		{
+			Name:     "sine_line",
+			Type:     plugin.ConfigTypeSwitch,
+			Title:    plugin.MakeTranslator(i18n.ConfigSineLineTitle),
+			Required: false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				Label: plugin.MakeTranslator(i18n.ConfigSineLineLabel),
+			},
+			Value: c.Config.SineLine,
+		},
+		{
+			Name:        "source",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigSourceTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigSourceDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.Source,
+		},
+		{
+			Name:     "exclude_ambiguous",
+			Type:     plugin.ConfigTypeSwitch,
+			Title:    plugin.MakeTranslator(i18n.ConfigExcludeAmbiguousTitle),
+			Required: false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				Label: plugin.MakeTranslator(i18n.ConfigExcludeAmbiguousLabel),
+			},
+			Value: c.Config.ExcludeAmbiguous,
+		},
+		{
+			Name:        "font",
+			Type:        plugin.ConfigTypeSelect,
+			Title:       plugin.MakeTranslator(i18n.ConfigFontTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigFontDescription),
+			Required:    false,
+			Options:     fontOptions,
+			Value:       c.Config.Font,
+		},
+		{
+			Name:     "normalize",
+			Type:     plugin.ConfigTypeSwitch,
+			Title:    plugin.MakeTranslator(i18n.ConfigNormalizeTitle),
+			Required: false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				Label: plugin.MakeTranslator(i18n.ConfigNormalizeLabel),
+			},
+			Value: c.Config.Normalize,
+		},
+		{
+			Name:        "max_attempts",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigMaxAttemptsTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigMaxAttemptsDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.MaxAttempts,
+		},
+		{
+			Name:     "audio",
+			Type:     plugin.ConfigTypeSwitch,
+			Title:    plugin.MakeTranslator(i18n.ConfigAudioTitle),
+			Required: false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				Label: plugin.MakeTranslator(i18n.ConfigAudioLabel),
+			},
+			Value: c.Config.Audio,
+		},
+		{
+			Name:        "audio_language",
+			Type:        plugin.ConfigTypeSelect,
+			Title:       plugin.MakeTranslator(i18n.ConfigAudioLanguageTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigAudioLanguageDescription),
+			Required:    false,
+			Options:     audioLanguageOptions(),
+			Value:       c.Config.AudioLanguage,
+		},
+	}
+}
+
+func (c *Captcha) ConfigReceiver(config []byte) error {
+	conf := defaultCaptchaConfig()
+	_ = json.Unmarshal(config, conf)
+	c.Config = conf
+	return nil
+}
+
+// audioLanguage is a language of the spoken digits embedded in base64Captcha
+type audioLanguage struct {
+	code  string
+	label string
+}
+
+// audioLanguages are both the config options and the languages accepted by the audio driver
+var audioLanguages = []audioLanguage{
+	{code: "en", label: i18n.ConfigAudioLanguageOptionsEn},
+	{code: "zh", label: i18n.ConfigAudioLanguageOptionsZh},
+	{code: "ja", label: i18n.ConfigAudioLanguageOptionsJa},
+	{code: "ru", label: i18n.ConfigAudioLanguageOptionsRu},
+	{code: "de", label: i18n.ConfigAudioLanguageOptionsDe},
+}
+
+func audioLanguageOptions() []plugin.ConfigFieldOption {
+	options := make([]plugin.ConfigFieldOption, 0, len(audioLanguages))
+	for _, language := range audioLanguages {
+		options = append(options, plugin.ConfigFieldOption{
+			Label: plugin.MakeTranslator(language.label),
+			Value: language.code,
+		})
+	}
+	return options
+}
+
+// literal returns a translator of a text that is the same in every language, such as a font name
+func literal(text string) plugin.Translator {
+	return plugin.Translator{Fn: func(ctx *plugin.GinContext) string {
+		return text
+	}}
 }
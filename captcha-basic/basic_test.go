@@ -0,0 +1,139 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package basic
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCaptcha_Modes(t *testing.T) {
+	for _, mode := range []string{ModeString, ModeMath, ModeDigit, ModeChinese} {
+		t.Run(mode, func(t *testing.T) {
+			conf := defaultCaptchaConfig()
+			conf.Mode, conf.Length, conf.NoiseCount, conf.SineLine = mode, "6", "3", true
+			c := &Captcha{Config: conf}
+
+			img, code := c.Create()
+			id, answer, _ := parseChallengeCode(code)
+			if !strings.HasPrefix(img, "data:") || len(id) == 0 || len(answer) == 0 {
+				t.Fatalf("Create() = %.32q, %q", img, code)
+			}
+			switch mode {
+			case ModeMath:
+				if _, err := strconv.Atoi(answer); err != nil {
+					t.Errorf("math answer %q is not a number", answer)
+				}
+			default:
+				if n := utf8.RuneCountInString(answer); n != 6 {
+					t.Errorf("answer %q has %d characters, want 6", answer, n)
+				}
+			}
+			if !c.Verify(code, answer) {
+				t.Error("Verify() rejected the answer")
+			}
+		})
+	}
+}
+
+func TestCaptchaConfig_Source(t *testing.T) {
+	conf := &CaptchaConfig{Source: "0O1lab", ExcludeAmbiguous: true}
+	if source := conf.source(); source != "ab" {
+		t.Errorf("source() = %q, want %q", source, "ab")
+	}
+	conf.Source = "0O1l"
+	if source := conf.source(); strings.ContainsAny(source, ambiguousChars) {
+		t.Errorf("source() = %q, want the default source without ambiguous characters", source)
+	}
+}
+
+func TestCaptcha_Verify(t *testing.T) {
+	tests := []struct {
+		name      string
+		normalize bool
+		answer    string
+		userInput string
+		pass      bool
+	}{
+		{name: "exact", answer: "ab12", userInput: "ab12", pass: true},
+		{name: "wrong", answer: "ab12", userInput: "ab13", pass: false},
+		{name: "case", answer: "ab12", userInput: "AB12", pass: false},
+		{name: "normalized case", normalize: true, answer: "ab12", userInput: "AB12", pass: true},
+		{name: "normalized spaces", normalize: true, answer: "ab12", userInput: " ab 12\t", pass: true},
+		{name: "normalized full-width", normalize: true, answer: "ab12", userInput: "ａｂ１２", pass: true},
+		{name: "normalized full-width space", normalize: true, answer: "ab12", userInput: "ab\u300012", pass: true},
+		{name: "normalized wrong", normalize: true, answer: "ab12", userInput: "ab13", pass: false},
+		{name: "empty", normalize: true, answer: "ab12", userInput: "", pass: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Captcha{Config: &CaptchaConfig{Normalize: tt.normalize}, attempts: newAttemptCounter()}
+			if pass := c.Verify(newChallengeCode(tt.answer, ""), tt.userInput); pass != tt.pass {
+				t.Errorf("Verify(%q, %q) = %v, want %v", tt.answer, tt.userInput, pass, tt.pass)
+			}
+		})
+	}
+}
+
+func TestCaptcha_MaxAttempts(t *testing.T) {
+	c := &Captcha{Config: &CaptchaConfig{MaxAttempts: "2"}, attempts: newAttemptCounter()}
+	code := newChallengeCode("ab12", "")
+	if c.Verify(code, "wrong") || c.Verify(code, "wrong") {
+		t.Fatal("Verify() accepted a wrong answer")
+	}
+	if c.Verify(code, "ab12") {
+		t.Error("Verify() accepted the answer after the max attempts")
+	}
+	if !c.Verify(newChallengeCode("ab12", ""), "ab12") {
+		t.Error("attempts of another challenge were counted")
+	}
+	// codes created by the previous versions only contain the answer
+	if !c.Verify("ab12", "ab12") {
+		t.Error("Verify() rejected a legacy code")
+	}
+}
+
+func TestCaptcha_Audio(t *testing.T) {
+	conf := defaultCaptchaConfig()
+	conf.Audio, conf.AudioLanguage = true, "zh"
+	c := &Captcha{Config: conf, attempts: newAttemptCounter()}
+
+	captcha, code := c.Create()
+	media := &challengeMedia{}
+	if err := json.Unmarshal([]byte(captcha), media); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(media.Image, "data:image/") || !strings.HasPrefix(media.Audio, "data:audio/") {
+		t.Fatalf("Create() = %.32q, %.32q", media.Image, media.Audio)
+	}
+	_, answer, audioAnswer := parseChallengeCode(code)
+	if _, err := strconv.Atoi(audioAnswer); err != nil {
+		t.Fatalf("audio answer %q is not digits", audioAnswer)
+	}
+	if !c.Verify(code, audioAnswer) {
+		t.Error("Verify() rejected the spoken digits")
+	}
+	if !c.Verify(code, answer) {
+		t.Error("Verify() rejected the image answer")
+	}
+}
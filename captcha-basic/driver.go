@@ -0,0 +1,194 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package basic
+
+import (
+	"image/color"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mojocn/base64Captcha"
+)
+
+const (
+	ModeString  = "string"
+	ModeMath    = "math"
+	ModeDigit   = "digit"
+	ModeChinese = "chinese"
+)
+
+const (
+	defaultLength   = 4
+	defaultWidth    = 200
+	defaultHeight   = 60
+	defaultDotCount = 80
+	defaultSource   = "1234567890qwertyuioplkjhgfdsazxcvbnm"
+	// ambiguousChars are removed from the source when ExcludeAmbiguous is enabled
+	ambiguousChars = "0oO1lIi"
+
+	defaultFont = "wqy-microhei.ttc"
+	// chineseFont is the only embedded font with Chinese glyphs
+	chineseFont = "wqy-microhei.ttc"
+	fontRandom  = "random"
+
+	defaultAudioLanguage = "en"
+)
+
+// fonts are the fonts embedded in base64Captcha, loading any other name panics
+var fonts = []string{
+	"wqy-microhei.ttc",
+	"3Dumb.ttf",
+	"ApothecaryFont.ttf",
+	"Comismsh.ttf",
+	"DENNEthree-dee.ttf",
+	"DeborahFancyDress.ttf",
+	"Flim-Flam.ttf",
+	"RitaSmith.ttf",
+	"actionj.ttf",
+	"chromohv.ttf",
+}
+
+var bgColor = &color.RGBA{R: 211, G: 211, B: 211, A: 0}
+
+// newDriver creates the base64Captcha driver of the configured mode
+func newDriver(conf *CaptchaConfig) base64Captcha.Driver {
+	length := positiveIntOrDefault(conf.Length, defaultLength)
+	width := positiveIntOrDefault(conf.Width, defaultWidth)
+	height := positiveIntOrDefault(conf.Height, defaultHeight)
+	noiseCount, _ := strconv.Atoi(conf.NoiseCount)
+	noiseCount = max(noiseCount, 0)
+	lineOptions := conf.lineOptions()
+
+	switch conf.Mode {
+	case ModeMath:
+		driver := &base64Captcha.DriverMath{
+			Height:          height,
+			Width:           width,
+			NoiseCount:      noiseCount,
+			ShowLineOptions: lineOptions,
+			BgColor:         bgColor,
+			Fonts:           conf.fonts(),
+		}
+		return driver.ConvertFonts()
+	case ModeDigit:
+		if noiseCount == 0 {
+			noiseCount = defaultDotCount
+		}
+		return base64Captcha.NewDriverDigit(height, width, length, 0.7, noiseCount)
+	case ModeChinese:
+		source := conf.Source
+		if len(source) == 0 {
+			source = base64Captcha.TxtChineseCharaters
+		}
+		driver := &base64Captcha.DriverChinese{
+			Height:          height,
+			Width:           width,
+			NoiseCount:      noiseCount,
+			ShowLineOptions: lineOptions,
+			Length:          length,
+			Source:          source,
+			BgColor:         bgColor,
+			Fonts:           []string{chineseFont},
+		}
+		return driver.ConvertFonts()
+	default:
+		driver := &base64Captcha.DriverString{
+			Height:          height,
+			Width:           width,
+			NoiseCount:      noiseCount,
+			ShowLineOptions: lineOptions,
+			Length:          length,
+			Source:          conf.source(),
+			BgColor:         bgColor,
+			Fonts:           conf.fonts(),
+		}
+		return driver.ConvertFonts()
+	}
+}
+
+// newAudioDriver creates the driver of the spoken digits offered along with the image
+func newAudioDriver(conf *CaptchaConfig) *base64Captcha.DriverAudio {
+	language := conf.AudioLanguage
+	supported := slices.ContainsFunc(audioLanguages, func(l audioLanguage) bool {
+		return l.code == language
+	})
+	if !supported {
+		language = defaultAudioLanguage
+	}
+	return base64Captcha.NewDriverAudio(positiveIntOrDefault(conf.Length, defaultLength), language)
+}
+
+func (conf *CaptchaConfig) lineOptions() (options int) {
+	if conf.HollowLine {
+		options |= base64Captcha.OptionShowHollowLine
+	}
+	if conf.SlimeLine {
+		options |= base64Captcha.OptionShowSlimeLine
+	}
+	if conf.SineLine {
+		options |= base64Captcha.OptionShowSineLine
+	}
+	return options
+}
+
+// source returns the characters of the string mode, falling back to the default when nothing is left
+func (conf *CaptchaConfig) source() string {
+	source := conf.Source
+	if conf.ExcludeAmbiguous {
+		source = excludeAmbiguous(source)
+	}
+	if len(strings.TrimSpace(source)) > 0 {
+		return source
+	}
+	if conf.ExcludeAmbiguous {
+		return excludeAmbiguous(defaultSource)
+	}
+	return defaultSource
+}
+
+func excludeAmbiguous(source string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(ambiguousChars, r) {
+			return -1
+		}
+		return r
+	}, source)
+}
+
+// fonts returns the configured font, nil lets base64Captcha pick a random embedded font for every character
+func (conf *CaptchaConfig) fonts() []string {
+	if conf.Font == fontRandom {
+		return nil
+	}
+	if !slices.Contains(fonts, conf.Font) {
+		return []string{defaultFont}
+	}
+	return []string{conf.Font}
+}
+
+// positiveIntOrDefault parses the numeric config value, empty or invalid values fall back to the default
+func positiveIntOrDefault(value string, defaultValue int) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return defaultValue
+	}
+	return n
+}
@@ -6,6 +6,8 @@ require (
 	github.com/apache/answer v1.7.0
 	github.com/apache/answer-plugins/util v1.0.3-0.20250107030257-cf94ebc70954
 	github.com/mojocn/base64Captcha v1.3.6
+	github.com/segmentfault/pacman v1.0.5-0.20230822083413-c0075a2d401f
+	golang.org/x/text v0.23.0
 )
 
 require (
@@ -35,7 +37,6 @@ require (
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/pelletier/go-toml/v2 v2.2.3 // indirect
-	github.com/segmentfault/pacman v1.0.5-0.20230822083413-c0075a2d401f // indirect
 	github.com/segmentfault/pacman/contrib/i18n v0.0.0-20230822083413-c0075a2d401f // indirect
 	github.com/syndtr/goleveldb v1.0.0 // indirect
 	github.com/tidwall/gjson v1.17.3 // indirect
@@ -49,7 +50,6 @@ require (
 	golang.org/x/image v0.20.0 // indirect
 	golang.org/x/net v0.38.0 // indirect
 	golang.org/x/sys v0.31.0 // indirect
-	golang.org/x/text v0.23.0 // indirect
 	google.golang.org/protobuf v1.34.2 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 	sigs.k8s.io/yaml v1.4.0 // indirect
@@ -22,4 +22,45 @@ package i18n
 const (
 	InfoName        = "plugin.basic_captcha.backend.info.name"
 	InfoDescription = "plugin.basic_captcha.backend.info.description"
+
+	ConfigModeTitle                = "plugin.basic_captcha.backend.config.mode.title"
+	ConfigModeDescription          = "plugin.basic_captcha.backend.config.mode.description"
+	ConfigModeOptionsString        = "plugin.basic_captcha.backend.config.mode.options.string"
+	ConfigModeOptionsMath          = "plugin.basic_captcha.backend.config.mode.options.math"
+	ConfigModeOptionsDigit         = "plugin.basic_captcha.backend.config.mode.options.digit"
+	ConfigModeOptionsChinese       = "plugin.basic_captcha.backend.config.mode.options.chinese"
+	ConfigLengthTitle              = "plugin.basic_captcha.backend.config.length.title"
+	ConfigLengthDescription        = "plugin.basic_captcha.backend.config.length.description"
+	ConfigWidthTitle               = "plugin.basic_captcha.backend.config.width.title"
+	ConfigWidthDescription         = "plugin.basic_captcha.backend.config.width.description"
+	ConfigHeightTitle              = "plugin.basic_captcha.backend.config.height.title"
+	ConfigHeightDescription        = "plugin.basic_captcha.backend.config.height.description"
+	ConfigNoiseCountTitle          = "plugin.basic_captcha.backend.config.noise_count.title"
+	ConfigNoiseCountDescription    = "plugin.basic_captcha.backend.config.noise_count.description"
+	ConfigHollowLineTitle          = "plugin.basic_captcha.backend.config.hollow_line.title"
+	ConfigHollowLineLabel          = "plugin.basic_captcha.backend.config.hollow_line.label"
+	ConfigSlimeLineTitle           = "plugin.basic_captcha.backend.config.slime_line.title"
+	ConfigSlimeLineLabel           = "plugin.basic_captcha.backend.config.slime_line.label"
+	ConfigSineLineTitle            = "plugin.basic_captcha.backend.config.sine_line.title"
+	ConfigSineLineLabel            = "plugin.basic_captcha.backend.config.sine_line.label"
+	ConfigSourceTitle              = "plugin.basic_captcha.backend.config.source.title"
+	ConfigSourceDescription        = "plugin.basic_captcha.backend.config.source.description"
+	ConfigExcludeAmbiguousTitle    = "plugin.basic_captcha.backend.config.exclude_ambiguous.title"
+	ConfigExcludeAmbiguousLabel    = "plugin.basic_captcha.backend.config.exclude_ambiguous.label"
+	ConfigFontTitle                = "plugin.basic_captcha.backend.config.font.title"
+	ConfigFontDescription          = "plugin.basic_captcha.backend.config.font.description"
+	ConfigFontOptionsRandom        = "plugin.basic_captcha.backend.config.font.options.random"
+	ConfigNormalizeTitle           = "plugin.basic_captcha.backend.config.normalize.title"
+	ConfigNormalizeLabel           = "plugin.basic_captcha.backend.config.normalize.label"
+	ConfigMaxAttemptsTitle         = "plugin.basic_captcha.backend.config.max_attempts.title"
+	ConfigMaxAttemptsDescription   = "plugin.basic_captcha.backend.config.max_attempts.description"
+	ConfigAudioTitle               = "plugin.basic_captcha.backend.config.audio.title"
+	ConfigAudioLabel               = "plugin.basic_captcha.backend.config.audio.label"
+	ConfigAudioLanguageTitle       = "plugin.basic_captcha.backend.config.audio_language.title"
+	ConfigAudioLanguageDescription = "plugin.basic_captcha.backend.config.audio_language.description"
+	ConfigAudioLanguageOptionsEn   = "plugin.basic_captcha.backend.config.audio_language.options.en"
+	ConfigAudioLanguageOptionsZh   = "plugin.basic_captcha.backend.config.audio_language.options.zh"
+	ConfigAudioLanguageOptionsJa   = "plugin.basic_captcha.backend.config.audio_language.options.ja"
+	ConfigAudioLanguageOptionsRu   = "plugin.basic_captcha.backend.config.audio_language.options.ru"
+	ConfigAudioLanguageOptionsDe   = "plugin.basic_captcha.backend.config.audio_language.options.de"
 )
@@ -23,6 +23,8 @@ import { useTranslation } from 'react-i18next';
 
 import ReactDOM from 'react-dom/client';
 
+import { ListenButton, parseCaptchaMedia } from './Captcha';
+
 import type {
   FormValue,
   ImgCodeRes,
@@ -228,6 +230,7 @@ const Index = ({
   }, [captcha, imgCode]);
 
   useEffect(() => {
+    const media = parseCaptchaMedia(captcha?.captcha_img);
     refRoot.current?.render(
       <Modal
         size="sm"
@@ -243,7 +246,7 @@ const Index = ({
             <Form.Group controlId="code" className="mb-3">
               <div className="mb-3 p-2 d-flex align-items-center justify-content-center bg-light rounded-2">
                 <img
-                  src={captcha?.captcha_img}
+                  src={media.image}
                   alt="captcha img"
                   width="auto"
                   height="60px"
@@ -253,11 +256,14 @@ const Index = ({
                 <Form.Control
                   type="text"
                   autoComplete="off"
-                  placeholder={t('placeholder')}
+                  placeholder={t(media.audio ? 'placeholder_audio' : 'placeholder')}
                   isInvalid={imgCode?.isInvalid}
                   onChange={handleChange}
                   value={imgCode.value}
                 />
+                {media.audio ? (
+                  <ListenButton src={media.audio} title={t('listen')} />
+                ) : null}
                 <Button
                   onClick={fetchCaptchaData}
                   variant="outline-secondary"
@@ -0,0 +1,125 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package basic
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+const (
+	defaultMaxAttempts = 3
+	// attemptsTTL is longer than the 6 minutes a captcha is kept by answer
+	attemptsTTL = 10 * time.Minute
+	// codeSeparator joins the challenge id and the answer in the code kept by answer
+	codeSeparator = ":"
+	// audioSeparator appends the digits of the audio challenge to the challenge id
+	audioSeparator = "-"
+)
+
+// newChallengeCode returns the code of a new challenge. It carries a random id,
+// so that attempts are counted per challenge even when two challenges have the same answer,
+// and the digits of the audio challenge if there is one.
+func newChallengeCode(answer, audioAnswer string) string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	id := hex.EncodeToString(b)
+	if len(audioAnswer) > 0 {
+		id += audioSeparator + audioAnswer
+	}
+	return id + codeSeparator + answer
+}
+
+// parseChallengeCode splits the code into the challenge id, the answer and the audio answer.
+// Codes created by the previous versions only contain the answer.
+func parseChallengeCode(code string) (id, answer, audioAnswer string) {
+	prefix, answer, ok := strings.Cut(code, codeSeparator)
+	if !ok {
+		return "", code, ""
+	}
+	id, audioAnswer, _ = strings.Cut(prefix, audioSeparator)
+	if len(id) != 16 {
+		return "", code, ""
+	}
+	return id, answer, audioAnswer
+}
+
+// normalize folds the differences that don't matter when typing a captcha:
+// spaces, letter case and full-width characters typed with CJK input methods
+func normalize(s string) string {
+	s = width.Narrow.String(s)
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+	return strings.ToLower(s)
+}
+
+func equalAnswer(answer, userInput string) bool {
+	return subtle.ConstantTimeCompare([]byte(answer), []byte(userInput)) == 1
+}
+
+// attemptCounter counts the verification attempts of every challenge in memory
+type attemptCounter struct {
+	mu        sync.Mutex
+	attempts  map[string]*attempts
+	lastSweep time.Time
+}
+
+type attempts struct {
+	count    int
+	expireAt time.Time
+}
+
+func newAttemptCounter() *attemptCounter {
+	return &attemptCounter{attempts: make(map[string]*attempts)}
+}
+
+// add records an attempt of the challenge and reports whether it is within the limit
+func (a *attemptCounter) add(id string, limit int) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	if now.Sub(a.lastSweep) > time.Minute {
+		for key, item := range a.attempts {
+			if now.After(item.expireAt) {
+				delete(a.attempts, key)
+			}
+		}
+		a.lastSweep = now
+	}
+
+	item, ok := a.attempts[id]
+	if !ok || now.After(item.expireAt) {
+		item = &attempts{expireAt: now.Add(attemptsTTL)}
+		a.attempts[id] = item
+	}
+	item.count++
+	return item.count <= limit
+}
@@ -0,0 +1,79 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package recaptcha
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxTokenLength is far above the length of the tokens issued by google
+const maxTokenLength = 8192
+
+// RespBody response body.
+type RespBody struct {
+	// http code
+	Code int `json:"code"`
+	// reason key
+	Reason string `json:"reason"`
+	// response message
+	Message string `json:"msg"`
+	// response data
+	Data interface{} `json:"data"`
+}
+
+// NewRespBodyData new response body with data
+func NewRespBodyData(code int, reason string, data interface{}) *RespBody {
+	return &RespBody{
+		Code:   code,
+		Reason: reason,
+		Data:   data,
+	}
+}
+
+type ReportTokenReq struct {
+	Token string `json:"token"`
+}
+
+func (c *Captcha) RegisterUnAuthRouter(r *gin.RouterGroup) {
+	r.POST("/google-v2-captcha/token", c.ReportToken)
+}
+
+func (c *Captcha) RegisterAuthUserRouter(r *gin.RouterGroup) {
+}
+
+func (c *Captcha) RegisterAuthAdminRouter(r *gin.RouterGroup) {
+}
+
+// ReportToken remembers the client ip of a solved token, it is sent to google when the token is verified.
+// Each client ip is rate limited, see remoteIPCache.
+func (c *Captcha) ReportToken(ctx *gin.Context) {
+	req := &ReportTokenReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil || len(req.Token) == 0 || len(req.Token) > maxTokenLength {
+		ctx.JSON(http.StatusBadRequest, NewRespBodyData(http.StatusBadRequest, "error", nil))
+		return
+	}
+	if !c.remoteIPs.set(req.Token, ctx.ClientIP()) {
+		ctx.JSON(http.StatusTooManyRequests, NewRespBodyData(http.StatusTooManyRequests, "error", nil))
+		return
+	}
+	ctx.JSON(http.StatusOK, NewRespBodyData(http.StatusOK, "success", nil))
+}
@@ -28,4 +28,7 @@ const (
 	ConfigSecretKeyDescription          = "plugin.google_v2_captcha.backend.config.secret_key.description"
 	ConfigSiteVerifyEndpointTitle       = "plugin.google_v2_captcha.backend.config.site_verify_endpoint.title"
 	ConfigSiteVerifyEndpointDescription = "plugin.google_v2_captcha.backend.config.site_verify_endpoint.description"
+	ConfigHostnamesTitle                = "plugin.google_v2_captcha.backend.config.hostnames.title"
+	ConfigHostnamesDescription          = "plugin.google_v2_captcha.backend.config.hostnames.description"
+	ConfigVerifyErrorTitle              = "plugin.google_v2_captcha.backend.config.verify_error.title"
 )
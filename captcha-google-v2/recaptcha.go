@@ -21,9 +21,13 @@ package recaptcha
 
 import (
 	"embed"
+	"fmt"
 	"github.com/apache/answer-plugins/util"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/apache/answer-plugins/captcha-google-v2/i18n"
@@ -34,27 +38,52 @@ import (
 	"github.com/segmentfault/pacman/log"
 )
 
+const defaultSiteVerifyEndpoint = "https://www.google.com/recaptcha/api/siteverify"
+
+// configErrorCodes are the error codes caused by the plugin configuration instead of the visitor
+var configErrorCodes = map[string]bool{
+	"missing-input-secret": true,
+	"invalid-input-secret": true,
+	"bad-request":          true,
+}
+
 //go:embed  info.yaml
 var Info embed.FS
 
 type Captcha struct {
-	Config *CaptchaConfig
+	Config    *CaptchaConfig
+	remoteIPs *remoteIPCache
+
+	mu        sync.Mutex
+	lastError *verifyError
 }
 
 type CaptchaConfig struct {
 	SiteKey            string `json:"site_key"`
 	SecretKey          string `json:"secret_key"`
 	SiteVerifyEndpoint string `json:"site_verify_endpoint"`
+	// Hostnames is the comma separated allowlist of the hostname the captcha was solved on,
+	// empty means the host of the site url
+	Hostnames string `json:"hostnames"`
 }
 
 type GoogleCaptchaResponse struct {
-	Success    bool     `json:"success"`
-	ErrorCodes []string `json:"error-codes"`
+	Success     bool     `json:"success"`
+	ChallengeTS string   `json:"challenge_ts"`
+	Hostname    string   `json:"hostname"`
+	ErrorCodes  []string `json:"error-codes"`
+}
+
+// verifyError is the last verification failure caused by the configuration, it is shown to the admin
+type verifyError struct {
+	message string
+	at      time.Time
 }
 
 func init() {
 	plugin.Register(&Captcha{
-		Config: &CaptchaConfig{},
+		Config:    &CaptchaConfig{},
+		remoteIPs: newRemoteIPCache(),
 	})
 }
 
@@ -91,33 +120,124 @@ func (c *Captcha) Verify(captcha, userInput string) (pass bool) {
 	cli.Timeout = 10 * time.Second
 	siteVerifyEndpoint := c.Config.SiteVerifyEndpoint
 	if siteVerifyEndpoint == "" {
-		siteVerifyEndpoint = "https://www.google.com/recaptcha/api/siteverify"
+		siteVerifyEndpoint = defaultSiteVerifyEndpoint
 	}
-	resp, err := cli.PostForm(siteVerifyEndpoint, map[string][]string{
+	form := map[string][]string{
 		"secret":   {c.Config.SecretKey},
 		"response": {userInput},
-	})
+	}
+	if remoteIP := c.remoteIPs.pop(userInput); len(remoteIP) > 0 {
+		form["remoteip"] = []string{remoteIP}
+	}
+	resp, err := cli.PostForm(siteVerifyEndpoint, form)
 	if err != nil {
 		log.Errorf("verify captcha error %s", err.Error())
+		c.setError(err.Error())
 		return false
 	}
 	defer resp.Body.Close()
 	all, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Errorf("verify captcha, read body error %s", err.Error())
+		c.setError(err.Error())
 		return false
 	}
 	r := &GoogleCaptchaResponse{}
-	_ = json.Unmarshal(all, r)
-	if r.Success {
-		return true
+	if err = json.Unmarshal(all, r); err != nil {
+		log.Errorf("verify captcha, parse response error %s, status is %d, response is %s", err.Error(), resp.StatusCode, string(all))
+		c.setError(fmt.Sprintf("unexpected response with status %d from %s", resp.StatusCode, siteVerifyEndpoint))
+		return false
+	}
+	if !r.Success {
+		var codes []string
+		for _, code := range r.ErrorCodes {
+			if configErrorCodes[code] {
+				codes = append(codes, code)
+			}
+		}
+		if len(codes) > 0 {
+			log.Errorf("verify captcha, the configuration is wrong, error codes %v", r.ErrorCodes)
+			c.setError(strings.Join(codes, ", "))
+			return false
+		}
+		log.Debugf("user input is wrong, error codes %v", r.ErrorCodes)
+		return false
+	}
+	c.setError("")
+	if hostnames := c.allowedHostnames(); len(hostnames) > 0 && !matchHostname(hostnames, r.Hostname) {
+		log.Debugf("captcha hostname %s is not allowed", r.Hostname)
+		return false
+	}
+	return true
+}
+
+// setError records the failure caused by the configuration, an empty message clears it
+func (c *Captcha) setError(message string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(message) == 0 {
+		c.lastError = nil
+		return
+	}
+	c.lastError = &verifyError{message: message, at: time.Now()}
+}
+
+func (c *Captcha) getError() *verifyError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastError
+}
+
+// allowedHostnames returns the configured hostnames, or the host of the site url by default
+func (c *Captcha) allowedHostnames() (hostnames []string) {
+	for _, hostname := range strings.Split(c.Config.Hostnames, ",") {
+		hostname = strings.ToLower(strings.TrimSpace(hostname))
+		if len(hostname) > 0 {
+			hostnames = append(hostnames, hostname)
+		}
+	}
+	if len(hostnames) > 0 {
+		return hostnames
+	}
+	if u, err := url.Parse(plugin.SiteURL()); err == nil && len(u.Hostname()) > 0 {
+		return []string{strings.ToLower(u.Hostname())}
+	}
+	return nil
+}
+
+// matchHostname reports whether the hostname is allowed, "*.example.com" matches any subdomain
+func matchHostname(allowed []string, hostname string) bool {
+	hostname = strings.ToLower(hostname)
+	for _, pattern := range allowed {
+		if pattern == hostname {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(pattern, "*"); ok && strings.HasPrefix(suffix, ".") &&
+			strings.HasSuffix(hostname, suffix) {
+			return true
+		}
 	}
-	log.Debugf("user input is wrong %s, user input is %s", string(all), userInput)
 	return false
 }
 
 func (c *Captcha) ConfigFields() []plugin.ConfigField {
-	return []plugin.ConfigField{
+	var fields []plugin.ConfigField
+	if verifyErr := c.getError(); verifyErr != nil {
+		fields = append(fields, plugin.ConfigField{
+			Name: "verify_error",
+			Type: plugin.ConfigTypeLegend,
+			Title: plugin.Translator{Fn: func(ctx *plugin.GinContext) string {
+				return fmt.Sprintf(plugin.Translate(ctx, i18n.ConfigVerifyErrorTitle),
+					verifyErr.at.Format(time.DateTime), verifyErr.message)
+			}},
+			Description: plugin.Translator{},
+			UIOptions: plugin.ConfigFieldUIOptions{
+				ClassName:      "mb-3",
+				FieldClassName: "mb-0 text-danger",
+			},
+		})
+	}
+	return append(fields, []plugin.ConfigField{
 		{
 			Name:        "site_key",
 			Type:        plugin.ConfigTypeInput,
@@ -151,12 +271,24 @@ func (c *Captcha) ConfigFields() []plugin.ConfigField {
 			},
 			Value: c.Config.SiteVerifyEndpoint,
 		},
-	}
+		{
+			Name:        "hostnames",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigHostnamesTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigHostnamesDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.Hostnames,
+		},
+	}...)
 }
 
 func (c *Captcha) ConfigReceiver(config []byte) error {
 	conf := &CaptchaConfig{}
 	_ = json.Unmarshal(config, conf)
 	c.Config = conf
+	c.setError("")
 	return nil
 }
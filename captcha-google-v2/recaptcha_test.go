@@ -0,0 +1,210 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package recaptcha
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCaptcha_Verify(t *testing.T) {
+	tests := []struct {
+		name      string
+		hostnames string
+		remoteIP  string
+		response  *GoogleCaptchaResponse
+		body      string
+		status    int
+		pass      bool
+		errored   bool
+	}{
+		{
+			name:     "success",
+			response: &GoogleCaptchaResponse{Success: true, Hostname: "answer.example.com"},
+			pass:     true,
+		},
+		{
+			name:     "wrong user input",
+			response: &GoogleCaptchaResponse{Success: false, ErrorCodes: []string{"invalid-input-response"}},
+			pass:     false,
+		},
+		{
+			name:     "duplicate token",
+			response: &GoogleCaptchaResponse{Success: false, ErrorCodes: []string{"timeout-or-duplicate"}},
+			pass:     false,
+		},
+		{
+			name:     "invalid secret",
+			response: &GoogleCaptchaResponse{Success: false, ErrorCodes: []string{"invalid-input-secret"}},
+			pass:     false,
+			errored:  true,
+		},
+		{
+			name:     "missing secret",
+			response: &GoogleCaptchaResponse{Success: false, ErrorCodes: []string{"missing-input-secret", "invalid-input-response"}},
+			pass:     false,
+			errored:  true,
+		},
+		{
+			name:    "not json",
+			body:    "<html>blocked</html>",
+			pass:    false,
+			errored: true,
+		},
+		{
+			name:    "server error",
+			status:  http.StatusInternalServerError,
+			pass:    false,
+			errored: true,
+		},
+		{
+			name:      "hostname allowed",
+			hostnames: "other.example.com, *.example.com",
+			response:  &GoogleCaptchaResponse{Success: true, Hostname: "answer.example.com"},
+			pass:      true,
+		},
+		{
+			name:      "hostname not allowed",
+			hostnames: "answer.example.com",
+			response:  &GoogleCaptchaResponse{Success: true, Hostname: "evil.example.org"},
+			pass:      false,
+		},
+		{
+			name:     "remote ip reported",
+			remoteIP: "192.0.2.1",
+			response: &GoogleCaptchaResponse{Success: true},
+			pass:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.PostFormValue("secret") != "secret" || r.PostFormValue("response") != "token" {
+					t.Errorf("unexpected form %v", r.PostForm)
+				}
+				if remoteIP := r.PostFormValue("remoteip"); remoteIP != tt.remoteIP {
+					t.Errorf("remoteip = %q, want %q", remoteIP, tt.remoteIP)
+				}
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+					return
+				}
+				if len(tt.body) > 0 {
+					_, _ = w.Write([]byte(tt.body))
+					return
+				}
+				_ = json.NewEncoder(w).Encode(tt.response)
+			}))
+			defer server.Close()
+
+			c := &Captcha{
+				Config: &CaptchaConfig{
+					SecretKey:          "secret",
+					SiteVerifyEndpoint: server.URL,
+					Hostnames:          tt.hostnames,
+				},
+				remoteIPs: newRemoteIPCache(),
+			}
+			if len(tt.remoteIP) > 0 {
+				c.remoteIPs.set("token", tt.remoteIP)
+			}
+			if pass := c.Verify("", "token"); pass != tt.pass {
+				t.Errorf("Verify() = %v, want %v", pass, tt.pass)
+			}
+			if errored := c.getError() != nil; errored != tt.errored {
+				t.Errorf("configuration error = %v, want %v", errored, tt.errored)
+			}
+			if shown := c.ConfigFields()[0].Name == "verify_error"; shown != tt.errored {
+				t.Errorf("configuration error shown = %v, want %v", shown, tt.errored)
+			}
+		})
+	}
+}
+
+func TestCaptcha_VerifyClearsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(&GoogleCaptchaResponse{Success: true})
+	}))
+	defer server.Close()
+
+	c := &Captcha{Config: &CaptchaConfig{SecretKey: "secret", SiteVerifyEndpoint: server.URL}}
+	c.setError("invalid-input-secret")
+	if !c.Verify("", "token") {
+		t.Fatal("Verify() = false, want true")
+	}
+	if c.getError() != nil {
+		t.Error("configuration error is not cleared after a successful verification")
+	}
+}
+
+func TestRemoteIPCache(t *testing.T) {
+	r := newRemoteIPCache()
+	r.set("token", "192.0.2.1")
+	if ip := r.pop("other"); ip != "" {
+		t.Errorf("pop(other) = %q, want empty", ip)
+	}
+	if ip := r.pop("token"); ip != "192.0.2.1" {
+		t.Errorf("pop(token) = %q, want 192.0.2.1", ip)
+	}
+	if ip := r.pop("token"); ip != "" {
+		t.Errorf("pop(token) twice = %q, want empty", ip)
+	}
+}
+
+func TestRemoteIPCache_Limits(t *testing.T) {
+	r := newRemoteIPCache()
+	for i := 0; i < maxTokensPerIP+1; i++ {
+		if !r.set(fmt.Sprintf("token%d", i), "192.0.2.1") {
+			t.Fatalf("set(token%d) rate limited", i)
+		}
+	}
+	r.set("other", "192.0.2.2")
+	// the oldest token of the ip is forgotten, the tokens of other ips are kept
+	if ip := r.pop("token0"); ip != "" {
+		t.Errorf("pop(token0) = %q, want empty", ip)
+	}
+	if ip := r.pop(fmt.Sprintf("token%d", maxTokensPerIP)); ip != "192.0.2.1" {
+		t.Errorf("pop(newest) = %q, want 192.0.2.1", ip)
+	}
+	if ip := r.pop("other"); ip != "192.0.2.2" {
+		t.Errorf("pop(other) = %q, want 192.0.2.2", ip)
+	}
+
+	// the first report of a token wins
+	r.set("shared", "192.0.2.3")
+	r.set("shared", "192.0.2.4")
+	if ip := r.pop("shared"); ip != "192.0.2.3" {
+		t.Errorf("pop(shared) = %q, want 192.0.2.3", ip)
+	}
+
+	limited := false
+	for i := 0; i < reportLimit+1; i++ {
+		limited = !r.set(fmt.Sprintf("flood%d", i), "192.0.2.5")
+	}
+	if !limited {
+		t.Error("set() is not rate limited")
+	}
+	if !r.set("token", "192.0.2.6") {
+		t.Error("set() of another ip is rate limited")
+	}
+}
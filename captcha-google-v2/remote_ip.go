@@ -0,0 +1,169 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package recaptcha
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+const (
+	// google only accepts tokens within two minutes, the ip is useless after that
+	remoteIPTTL = 2 * time.Minute
+	// maxRemoteIPs bounds the memory used by visitors reporting tokens that are never verified
+	maxRemoteIPs = 100000
+	// maxTokensPerIP bounds the tokens kept for a single ip, its oldest token is forgotten for a new one,
+	// so a single client can't fill the cache and evict the tokens of the others
+	maxTokensPerIP = 10
+	// reportLimit is the number of reports accepted from a single ip within reportWindow
+	reportLimit  = 20
+	reportWindow = time.Minute
+)
+
+// remoteIPCache remembers the ip of the visitor who solved a token, so it can be sent to siteverify as remoteip.
+// The captcha interface does not pass the request to Verify, the widget reports the token right after it is solved.
+type remoteIPCache struct {
+	mu  sync.Mutex
+	ips map[string]*remoteIP
+	// tokens are the token keys reported by each ip, the oldest first
+	tokens map[string][]string
+	// reports counts the reports of each ip in the current window
+	reports   map[string]*reportCount
+	lastSweep time.Time
+}
+
+type remoteIP struct {
+	ip       string
+	expireAt time.Time
+}
+
+type reportCount struct {
+	count   int
+	resetAt time.Time
+}
+
+func newRemoteIPCache() *remoteIPCache {
+	return &remoteIPCache{
+		ips:     make(map[string]*remoteIP),
+		tokens:  make(map[string][]string),
+		reports: make(map[string]*reportCount),
+	}
+}
+
+// set records the ip of the token and reports false when the ip sent too many reports.
+// The first report of a token wins, and the token is ignored when the cache is full.
+func (r *remoteIPCache) set(token, ip string) bool {
+	if r == nil {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	if now.Sub(r.lastSweep) > time.Minute || len(r.ips) >= maxRemoteIPs {
+		r.sweep(now)
+	}
+
+	report := r.reports[ip]
+	if report == nil || now.After(report.resetAt) {
+		report = &reportCount{resetAt: now.Add(reportWindow)}
+		r.reports[ip] = report
+	}
+	if report.count >= reportLimit {
+		return false
+	}
+	report.count++
+
+	key := tokenKey(token)
+	if _, ok := r.ips[key]; ok {
+		return true
+	}
+	tokens := r.liveTokens(ip)
+	if len(tokens) >= maxTokensPerIP {
+		delete(r.ips, tokens[0])
+		tokens = tokens[1:]
+	}
+	if len(r.ips) >= maxRemoteIPs {
+		r.tokens[ip] = tokens
+		return true
+	}
+	r.ips[key] = &remoteIP{ip: ip, expireAt: now.Add(remoteIPTTL)}
+	r.tokens[ip] = append(tokens, key)
+	return true
+}
+
+// liveTokens returns the tokens of the ip which are neither verified nor swept
+func (r *remoteIPCache) liveTokens(ip string) []string {
+	var tokens []string
+	for _, key := range r.tokens[ip] {
+		if item, ok := r.ips[key]; ok && item.ip == ip {
+			tokens = append(tokens, key)
+		}
+	}
+	return tokens
+}
+
+// sweep forgets the expired tokens and report windows
+func (r *remoteIPCache) sweep(now time.Time) {
+	for key, item := range r.ips {
+		if now.After(item.expireAt) {
+			delete(r.ips, key)
+		}
+	}
+	for ip := range r.tokens {
+		if tokens := r.liveTokens(ip); len(tokens) > 0 {
+			r.tokens[ip] = tokens
+		} else {
+			delete(r.tokens, ip)
+		}
+	}
+	for ip, report := range r.reports {
+		if now.After(report.resetAt) {
+			delete(r.reports, ip)
+		}
+	}
+	r.lastSweep = now
+}
+
+// pop returns and forgets the ip of the token, tokens can only be verified once
+func (r *remoteIPCache) pop(token string) string {
+	if r == nil {
+		return ""
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := tokenKey(token)
+	item, ok := r.ips[key]
+	if !ok {
+		return ""
+	}
+	delete(r.ips, key)
+	if time.Now().After(item.expireAt) {
+		return ""
+	}
+	return item.ip
+}
+
+// tokenKey hashes the token, tokens are a few thousand bytes long
+func tokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
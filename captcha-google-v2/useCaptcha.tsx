@@ -58,6 +58,25 @@ const checkImgCode = ({
   });
 };
 
+/**
+ * Reports the solved token, so the server can send the client ip to google when the token is verified.
+ * The submit waits for it, otherwise the token may be verified before its ip is recorded.
+ * A failed report only means the token is verified without the ip.
+ */
+const reportToken = (token: string) => {
+  return fetch('/answer/api/v1/google-v2-captcha/token', {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    body: JSON.stringify({ token }),
+  })
+    .then(() => undefined)
+    .catch((e) => {
+      console.log('reportToken', e);
+    });
+};
+
 type SubmitCallback = {
   (): void;
 };
@@ -78,10 +97,12 @@ const Index = ({
 
   const refKey = useRef<CaptchaKey>(captchaKey);
   const refCallback = useRef<SubmitCallback>();
+  const refReport = useRef<Promise<void>>(Promise.resolve());
   const pending = useRef(false);
   const autoInitCaptchaData = /email/i.test(refKey.current);
 
   const [isLoading, setIsLoading] = useState(true);
+  const [reporting, setReporting] = useState(false);
   const [stateShow, setStateShow] = useState(false);
   const [googleKey, setGoogleKey] = useState('');
   const [captcha, setCaptcha] = useState<ImgCodeRes>({
@@ -194,6 +215,12 @@ const Index = ({
   };
 
   const handleChange = (token) => {
+    if (token) {
+      setReporting(true);
+      refReport.current = reportToken(token).finally(() => {
+        setReporting(false);
+      });
+    }
     setImgCode({
       value: token || '',
       isInvalid: false,
@@ -221,12 +248,13 @@ const Index = ({
     }
   };
 
-  const handleSubmit = (evt) => {
+  const handleSubmit = async (evt) => {
     evt.preventDefault();
     if (!imgCode.value) {
       return;
     }
 
+    await refReport.current;
     if (refCallback.current) {
       refCallback.current();
     }
@@ -300,7 +328,7 @@ const Index = ({
             </Form.Group>
 
             <div className="d-grid">
-              <Button type="submit" disabled={!imgCode.value}>
+              <Button type="submit" disabled={!imgCode.value || reporting}>
                 {t('verify')}
               </Button>
             </div>
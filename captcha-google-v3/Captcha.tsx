@@ -0,0 +1,64 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+declare global {
+  interface Window {
+    grecaptcha?: any;
+  }
+}
+
+let scriptPromise: Promise<void> | null = null;
+
+const loadScript = (sitekey: string, language?: string) => {
+  if (window.grecaptcha?.execute) {
+    return Promise.resolve();
+  }
+  if (!scriptPromise) {
+    scriptPromise = new Promise<void>((resolve, reject) => {
+      const script = document.createElement('script');
+      script.src = `https://www.google.com/recaptcha/api.js?render=${encodeURIComponent(sitekey)}${language ? `&hl=${language}` : ''}`;
+      script.async = true;
+      script.onload = () => window.grecaptcha.ready(() => resolve());
+      script.onerror = () => {
+        scriptPromise = null;
+        reject(new Error('load recaptcha script failed'));
+      };
+      document.head.appendChild(script);
+    });
+  }
+  return scriptPromise;
+};
+
+/**
+ * Runs reCAPTCHA v3 in the background and resolves the token of the action.
+ * Action names may only contain alphanumeric characters, slashes and underscores.
+ */
+export const execute = (sitekey: string, action: string, language?: string) => {
+  return loadScript(sitekey, language).then(
+    () => window.grecaptcha.execute(sitekey, { action }) as Promise<string>,
+  );
+};
+
+const Index = () => {
+  return (
+    <div>google v3</div>
+  );
+};
+
+export default Index;
@@ -0,0 +1,39 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package i18n
+
+const (
+	InfoName                            = "plugin.google_v3_captcha.backend.info.name"
+	InfoDescription                     = "plugin.google_v3_captcha.backend.info.description"
+	ConfigSiteKeyTitle                  = "plugin.google_v3_captcha.backend.config.site_key.title"
+	ConfigSiteKeyDescription            = "plugin.google_v3_captcha.backend.config.site_key.description"
+	ConfigSecretKeyTitle                = "plugin.google_v3_captcha.backend.config.secret_key.title"
+	ConfigSecretKeyDescription          = "plugin.google_v3_captcha.backend.config.secret_key.description"
+	ConfigSiteVerifyEndpointTitle       = "plugin.google_v3_captcha.backend.config.site_verify_endpoint.title"
+	ConfigSiteVerifyEndpointDescription = "plugin.google_v3_captcha.backend.config.site_verify_endpoint.description"
+	ConfigScoreThresholdTitle           = "plugin.google_v3_captcha.backend.config.score_threshold.title"
+	ConfigScoreThresholdDescription     = "plugin.google_v3_captcha.backend.config.score_threshold.description"
+	ConfigActionThresholdsTitle         = "plugin.google_v3_captcha.backend.config.action_thresholds.title"
+	ConfigActionThresholdsDescription   = "plugin.google_v3_captcha.backend.config.action_thresholds.description"
+	ConfigMaxAgeTitle                   = "plugin.google_v3_captcha.backend.config.max_age.title"
+	ConfigMaxAgeDescription             = "plugin.google_v3_captcha.backend.config.max_age.description"
+	ConfigHostnamesTitle                = "plugin.google_v3_captcha.backend.config.hostnames.title"
+	ConfigHostnamesDescription          = "plugin.google_v3_captcha.backend.config.hostnames.description"
+)
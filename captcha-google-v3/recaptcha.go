@@ -0,0 +1,356 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package recaptcha
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/answer-plugins/captcha-google-v3/i18n"
+	"github.com/apache/answer-plugins/util"
+	"github.com/apache/answer/plugin"
+	"github.com/segmentfault/pacman/log"
+)
+
+const (
+	defaultSiteVerifyEndpoint = "https://www.google.com/recaptcha/api/siteverify"
+	defaultScoreThreshold     = 0.5
+	// google only accepts tokens within two minutes, so that is the default max age
+	defaultMaxAge = 120
+	// nonceSize is the number of random bytes of the nonce issued with every captcha
+	nonceSize = 16
+)
+
+// actions are the captcha keys of the answer pages, the widget executes with one of them as the action name
+var actions = []string{
+	"email",
+	"password",
+	"edit_userinfo",
+	"question",
+	"answer",
+	"comment",
+	"edit",
+	"invitation_answer",
+	"search",
+	"report",
+	"delete",
+	"vote",
+}
+
+//go:embed  info.yaml
+var Info embed.FS
+
+type Captcha struct {
+	Config *CaptchaConfig
+}
+
+type CaptchaConfig struct {
+	SiteKey            string `json:"site_key"`
+	SecretKey          string `json:"secret_key"`
+	SiteVerifyEndpoint string `json:"site_verify_endpoint"`
+	ScoreThreshold     string `json:"score_threshold"`
+	// ActionThresholds overrides the score threshold per action, one "action=score" per line
+	ActionThresholds string `json:"action_thresholds"`
+	MaxAge           string `json:"max_age"`
+	// Hostnames is the comma separated allowlist of the hostname the token was issued on,
+	// empty means the host of the site url
+	Hostnames string `json:"hostnames"`
+}
+
+type GoogleCaptchaResponse struct {
+	Success     bool     `json:"success"`
+	Score       float64  `json:"score"`
+	Action      string   `json:"action"`
+	ChallengeTS string   `json:"challenge_ts"`
+	Hostname    string   `json:"hostname"`
+	ErrorCodes  []string `json:"error-codes"`
+}
+
+func init() {
+	plugin.Register(&Captcha{
+		Config: &CaptchaConfig{},
+	})
+}
+
+func (c *Captcha) Info() plugin.Info {
+	info := &util.Info{}
+	info.GetInfo(Info)
+
+	return plugin.Info{
+		Name:        plugin.MakeTranslator(i18n.InfoName),
+		SlugName:    info.SlugName,
+		Description: plugin.MakeTranslator(i18n.InfoDescription),
+		Author:      info.Author,
+		Version:     info.Version,
+		Link:        info.Link,
+	}
+}
+
+func (c *Captcha) GetConfig() (config string) {
+	data, _ := json.Marshal(map[string]interface{}{
+		"key": c.Config.SiteKey,
+	})
+	return string(data)
+}
+
+// Create issues a nonce as both the captcha and the code, the page executes reCAPTCHA with
+// the nonce appended to its action, so a token is only accepted with the captcha it was requested for
+func (c *Captcha) Create() (captcha, code string) {
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		log.Errorf("create captcha nonce error %s", err.Error())
+		return "", ""
+	}
+	code = hex.EncodeToString(nonce)
+	return code, code
+}
+
+func (c *Captcha) Verify(captcha, userInput string) (pass bool) {
+	// without the code the captcha is expired or was never issued
+	if len(captcha) == 0 || len(userInput) == 0 {
+		return false
+	}
+	cli := &http.Client{}
+	cli.Timeout = 10 * time.Second
+	siteVerifyEndpoint := c.Config.SiteVerifyEndpoint
+	if siteVerifyEndpoint == "" {
+		siteVerifyEndpoint = defaultSiteVerifyEndpoint
+	}
+	resp, err := cli.PostForm(siteVerifyEndpoint, map[string][]string{
+		"secret":   {c.Config.SecretKey},
+		"response": {userInput},
+	})
+	if err != nil {
+		log.Errorf("verify captcha error %s", err.Error())
+		return false
+	}
+	defer resp.Body.Close()
+	all, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Errorf("verify captcha, read body error %s", err.Error())
+		return false
+	}
+	r := &GoogleCaptchaResponse{}
+	if err = json.Unmarshal(all, r); err != nil {
+		log.Errorf("verify captcha, parse response error %s, response is %s", err.Error(), string(all))
+		return false
+	}
+	if !r.Success {
+		log.Debugf("user input is wrong, error codes %v", r.ErrorCodes)
+		return false
+	}
+
+	action, nonce, ok := strings.Cut(r.Action, "/")
+	if !ok || subtle.ConstantTimeCompare([]byte(nonce), []byte(captcha)) != 1 {
+		log.Debugf("captcha action %s is not issued for the captcha", r.Action)
+		return false
+	}
+	thresholds := c.actionThresholds()
+	threshold, ok := thresholds[action]
+	if !ok {
+		log.Debugf("captcha action %s is not issued by any page", action)
+		return false
+	}
+	if r.Score < threshold {
+		log.Debugf("captcha score %.1f of action %s is lower than %.1f", r.Score, action, threshold)
+		return false
+	}
+	if maxAge := c.maxAge(); maxAge > 0 {
+		challengeTS, err := time.Parse(time.RFC3339, r.ChallengeTS)
+		if err != nil {
+			log.Debugf("captcha challenge_ts %s is invalid", r.ChallengeTS)
+			return false
+		}
+		if time.Since(challengeTS) > maxAge {
+			log.Debugf("captcha issued at %s is expired", r.ChallengeTS)
+			return false
+		}
+	}
+	if hostnames := c.allowedHostnames(); len(hostnames) > 0 && !matchHostname(hostnames, r.Hostname) {
+		log.Debugf("captcha hostname %s is not allowed", r.Hostname)
+		return false
+	}
+	return true
+}
+
+// actionThresholds returns the minimum score of every action a page may issue,
+// actions configured in action_thresholds are accepted as well
+func (c *Captcha) actionThresholds() map[string]float64 {
+	threshold := scoreOrDefault(c.Config.ScoreThreshold, defaultScoreThreshold)
+	thresholds := make(map[string]float64, len(actions))
+	for _, action := range actions {
+		thresholds[action] = threshold
+	}
+	for _, line := range strings.FieldsFunc(c.Config.ActionThresholds, func(r rune) bool {
+		return r == '\n' || r == ','
+	}) {
+		action, score, ok := strings.Cut(line, "=")
+		action = strings.TrimSpace(action)
+		if !ok || len(action) == 0 {
+			continue
+		}
+		thresholds[action] = scoreOrDefault(score, threshold)
+	}
+	return thresholds
+}
+
+func (c *Captcha) maxAge() time.Duration {
+	maxAge, err := strconv.Atoi(strings.TrimSpace(c.Config.MaxAge))
+	if err != nil || maxAge < 0 {
+		maxAge = defaultMaxAge
+	}
+	return time.Duration(maxAge) * time.Second
+}
+
+// allowedHostnames returns the configured hostnames, or the host of the site url by default
+func (c *Captcha) allowedHostnames() (hostnames []string) {
+	for _, hostname := range strings.Split(c.Config.Hostnames, ",") {
+		hostname = strings.ToLower(strings.TrimSpace(hostname))
+		if len(hostname) > 0 {
+			hostnames = append(hostnames, hostname)
+		}
+	}
+	if len(hostnames) > 0 {
+		return hostnames
+	}
+	if u, err := url.Parse(plugin.SiteURL()); err == nil && len(u.Hostname()) > 0 {
+		return []string{strings.ToLower(u.Hostname())}
+	}
+	return nil
+}
+
+// matchHostname reports whether the hostname is allowed, "*.example.com" matches any subdomain
+func matchHostname(allowed []string, hostname string) bool {
+	hostname = strings.ToLower(hostname)
+	for _, pattern := range allowed {
+		if pattern == hostname {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(pattern, "*"); ok && strings.HasPrefix(suffix, ".") &&
+			strings.HasSuffix(hostname, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// scoreOrDefault parses a score between 0 and 1, falling back to def
+func scoreOrDefault(value string, def float64) float64 {
+	score, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || score < 0 || score > 1 {
+		return def
+	}
+	return score
+}
+
+func (c *Captcha) ConfigFields() []plugin.ConfigField {
+	return []plugin.ConfigField{
+		{
+			Name:        "site_key",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigSiteKeyTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigSiteKeyDescription),
+			Required:    true,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.SiteKey,
+		},
+		{
+			Name:        "secret_key",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigSecretKeyTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigSecretKeyDescription),
+			Required:    true,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.SecretKey,
+		},
+		{
+			Name:        "site_verify_endpoint",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigSiteVerifyEndpointTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigSiteVerifyEndpointDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.SiteVerifyEndpoint,
+		},
+		{
+			Name:        "score_threshold",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigScoreThresholdTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigScoreThresholdDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.ScoreThreshold,
+		},
+		{
+			Name:        "action_thresholds",
+			Type:        plugin.ConfigTypeTextarea,
+			Title:       plugin.MakeTranslator(i18n.ConfigActionThresholdsTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigActionThresholdsDescription),
+			Required:    false,
+			Value:       c.Config.ActionThresholds,
+		},
+		{
+			Name:        "max_age",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigMaxAgeTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigMaxAgeDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.MaxAge,
+		},
+		{
+			Name:        "hostnames",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigHostnamesTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigHostnamesDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.Hostnames,
+		},
+	}
+}
+
+func (c *Captcha) ConfigReceiver(config []byte) error {
+	conf := &CaptchaConfig{}
+	_ = json.Unmarshal(config, conf)
+	c.Config = conf
+	return nil
+}
@@ -0,0 +1,193 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package recaptcha
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testNonce = "0123456789abcdef0123456789abcdef"
+
+func TestCaptcha_Create(t *testing.T) {
+	c := &Captcha{Config: &CaptchaConfig{}}
+	captcha, code := c.Create()
+	if len(code) != 2*nonceSize || captcha != code {
+		t.Fatalf("Create() = %s, %s", captcha, code)
+	}
+	if _, other := c.Create(); other == code {
+		t.Error("Create() issues the same nonce twice")
+	}
+}
+
+func TestCaptcha_VerifyBindsCaptcha(t *testing.T) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	tests := []struct {
+		name   string
+		code   string
+		action string
+		pass   bool
+	}{
+		{name: "nonce of the captcha", code: testNonce, action: "question/" + testNonce, pass: true},
+		{name: "nonce of another captcha", code: testNonce, action: "question/fedcba9876543210fedcba9876543210"},
+		{name: "action without nonce", code: testNonce, action: "question"},
+		{name: "expired captcha", code: "", action: "question/"},
+		{name: "unknown action with the nonce", code: testNonce, action: "checkout/" + testNonce},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewEncoder(w).Encode(&GoogleCaptchaResponse{
+					Success: true, Score: 0.9, Action: tt.action, ChallengeTS: now,
+				})
+			}))
+			defer server.Close()
+
+			c := &Captcha{Config: &CaptchaConfig{SecretKey: "secret", SiteVerifyEndpoint: server.URL}}
+			if pass := c.Verify(tt.code, "token"); pass != tt.pass {
+				t.Errorf("Verify() = %v, want %v", pass, tt.pass)
+			}
+		})
+	}
+}
+
+func TestCaptcha_Verify(t *testing.T) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	old := time.Now().Add(-10 * time.Minute).UTC().Format(time.RFC3339)
+	tests := []struct {
+		name     string
+		config   CaptchaConfig
+		response *GoogleCaptchaResponse
+		status   int
+		pass     bool
+	}{
+		{
+			name:     "success",
+			response: &GoogleCaptchaResponse{Success: true, Score: 0.9, Action: "question", ChallengeTS: now},
+			pass:     true,
+		},
+		{
+			name:     "failure",
+			response: &GoogleCaptchaResponse{Success: false, ErrorCodes: []string{"invalid-input-response"}},
+			pass:     false,
+		},
+		{
+			name:     "score lower than default threshold",
+			response: &GoogleCaptchaResponse{Success: true, Score: 0.3, Action: "question", ChallengeTS: now},
+			pass:     false,
+		},
+		{
+			name:     "score lower than configured threshold",
+			config:   CaptchaConfig{ScoreThreshold: "0.8"},
+			response: &GoogleCaptchaResponse{Success: true, Score: 0.7, Action: "question", ChallengeTS: now},
+			pass:     false,
+		},
+		{
+			name:     "action threshold overrides default",
+			config:   CaptchaConfig{ActionThresholds: "search=0.1\nquestion = 0.9"},
+			response: &GoogleCaptchaResponse{Success: true, Score: 0.2, Action: "search", ChallengeTS: now},
+			pass:     true,
+		},
+		{
+			name:     "action threshold rejects",
+			config:   CaptchaConfig{ActionThresholds: "search=0.1\nquestion = 0.9"},
+			response: &GoogleCaptchaResponse{Success: true, Score: 0.8, Action: "question", ChallengeTS: now},
+			pass:     false,
+		},
+		{
+			name:     "unknown action",
+			response: &GoogleCaptchaResponse{Success: true, Score: 0.9, Action: "checkout", ChallengeTS: now},
+			pass:     false,
+		},
+		{
+			name:     "configured action",
+			config:   CaptchaConfig{ActionThresholds: "checkout=0.5"},
+			response: &GoogleCaptchaResponse{Success: true, Score: 0.9, Action: "checkout", ChallengeTS: now},
+			pass:     true,
+		},
+		{
+			name:     "expired token",
+			response: &GoogleCaptchaResponse{Success: true, Score: 0.9, Action: "question", ChallengeTS: old},
+			pass:     false,
+		},
+		{
+			name:     "max age disabled",
+			config:   CaptchaConfig{MaxAge: "0"},
+			response: &GoogleCaptchaResponse{Success: true, Score: 0.9, Action: "question", ChallengeTS: old},
+			pass:     true,
+		},
+		{
+			name:     "invalid challenge ts",
+			response: &GoogleCaptchaResponse{Success: true, Score: 0.9, Action: "question"},
+			pass:     false,
+		},
+		{
+			name:   "hostname allowed",
+			config: CaptchaConfig{Hostnames: "*.example.com"},
+			response: &GoogleCaptchaResponse{
+				Success: true, Score: 0.9, Action: "question", ChallengeTS: now, Hostname: "answer.example.com",
+			},
+			pass: true,
+		},
+		{
+			name:   "hostname not allowed",
+			config: CaptchaConfig{Hostnames: "answer.example.com"},
+			response: &GoogleCaptchaResponse{
+				Success: true, Score: 0.9, Action: "question", ChallengeTS: now, Hostname: "evil.example.org",
+			},
+			pass: false,
+		},
+		{
+			name:   "server error",
+			status: http.StatusInternalServerError,
+			pass:   false,
+		},
+	}
+	for _, tt := range tests {
+		if tt.response != nil && len(tt.response.Action) > 0 {
+			// the page appends the nonce of the captcha to its action
+			tt.response.Action += "/" + testNonce
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.PostFormValue("secret") != "secret" || r.PostFormValue("response") != "token" {
+					t.Errorf("unexpected form %v", r.PostForm)
+				}
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+					return
+				}
+				_ = json.NewEncoder(w).Encode(tt.response)
+			}))
+			defer server.Close()
+
+			conf := tt.config
+			conf.SecretKey = "secret"
+			conf.SiteVerifyEndpoint = server.URL
+			c := &Captcha{Config: &conf}
+			if pass := c.Verify(testNonce, "token"); pass != tt.pass {
+				t.Errorf("Verify() = %v, want %v", pass, tt.pass)
+			}
+		})
+	}
+}
@@ -0,0 +1,323 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import { useEffect, useRef, useState, useLayoutEffect } from 'react';
+import { Modal, Button, Spinner } from 'react-bootstrap';
+import { useTranslation } from 'react-i18next';
+
+import ReactDOM from 'react-dom/client';
+
+import { execute } from './Captcha';
+import { languageKeys } from './common';
+import type {
+  GoogleV3Config,
+  FormValue,
+  ImgCodeRes,
+  CaptchaKey,
+  FieldError,
+  ImgCodeReq,
+} from './interface';
+
+export interface Props {
+  captchaKey: CaptchaKey;
+  commonProps?: any;
+}
+
+const checkImgCode = ({
+  captchaKey,
+  commonProps,
+}: Props) => {
+  return new Promise<ImgCodeRes>((resolve) => {
+    fetch(`/answer/api/v1/user/action/record?action=${captchaKey}`, {
+      headers: {
+        ...commonProps?.headers
+      }
+    })
+      .then((resp) => {
+        return resp.json();
+      })
+      .then((data) => {
+        console.log('checkImgCode', data);
+        resolve(data.data);
+      });
+  });
+};
+
+const fetchCaptchaConfig = () => {
+  return fetch(`/answer/api/v1/captcha/config`)
+    .then((resp) => {
+      return resp.json();
+    })
+    .then((data) => {
+      return (data?.data?.config || {}) as GoogleV3Config;
+    });
+};
+
+type SubmitCallback = {
+  (): void;
+};
+
+const Index = ({
+  captchaKey,
+  commonProps,
+}: Props) => {
+  const refRoot = useRef<ReactDOM.Root | null>(null);
+
+  if (refRoot.current === null) {
+    refRoot.current = ReactDOM.createRoot(document.createElement('div'));
+  }
+
+  const { t, i18n } = useTranslation('plugin', {
+    keyPrefix: 'google_v3_captcha.frontend',
+  });
+
+  const refKey = useRef<CaptchaKey>(captchaKey);
+  const refCallback = useRef<SubmitCallback>();
+  const pending = useRef(false);
+  const autoInitCaptchaData = /email/i.test(refKey.current);
+
+  const [executing, setExecuting] = useState(false);
+  const [stateShow, setStateShow] = useState(false);
+  const [captcha, setCaptcha] = useState<ImgCodeRes>({
+    captcha_id: '',
+    captcha_img: '',
+    verify: false,
+  });
+  const [imgCode, setImgCode] = useState<FormValue>({
+    value: '',
+    isInvalid: false,
+    errorMsg: '',
+  });
+  const refCaptcha = useRef(captcha);
+  const refImgCode = useRef(imgCode);
+
+  const fetchCaptchaData = () => {
+    pending.current = true;
+    return checkImgCode({
+      captchaKey: refKey.current,
+      commonProps,
+    })
+      .then((resp) => {
+        refCaptcha.current = resp;
+        setCaptcha(resp);
+      })
+      .finally(() => {
+        pending.current = false;
+      });
+  };
+
+  const resetCapture = () => {
+    setCaptcha({
+      captcha_id: '',
+      captcha_img: '',
+      verify: false,
+    });
+  };
+
+  const resetImgCode = () => {
+    setImgCode({
+      value: '',
+      isInvalid: false,
+      errorMsg: '',
+    });
+  };
+  const resetCallback = () => {
+    refCallback.current = undefined;
+  };
+
+  const show = () => {
+    if (!stateShow) {
+      setStateShow(true);
+    }
+  };
+  /**
+   * There are some cases where the React scheduler cancels the execution of some functions,
+   * which prevents them from closing properly:
+   *  for example, if the parent component uninstalls the child component directly,
+   *  and the `captchaModal.close()` call is inside the child component.
+   * In this case, call `await captchaModal.close()` and wait for the close action to complete.
+   */
+  const close = () => {
+    setStateShow(false);
+    resetCapture();
+    resetImgCode();
+    resetCallback();
+
+    const p = new Promise<void>((resolve) => {
+      setTimeout(resolve, 50);
+    });
+    return p;
+  };
+
+  /**
+   * reCAPTCHA v3 has no challenge for the visitor to solve,
+   * the token is requested in the background and the pending submit is replayed with it.
+   */
+  const executeAndSubmit = () => {
+    pending.current = true;
+    setExecuting(true);
+    fetchCaptchaConfig()
+      .then((config) => {
+        // the nonce of the captcha binds the token to it, the backend rejects a token of another captcha
+        const action = `${refKey.current}/${refCaptcha.current?.captcha_img || ''}`;
+        return execute(config.key, action, languageKeys[i18n.language]);
+      })
+      .then((token) => {
+        const code = {
+          value: token || '',
+          isInvalid: false,
+          errorMsg: '',
+        };
+        refImgCode.current = code;
+        setImgCode(code);
+        pending.current = false;
+        refCallback.current?.();
+      })
+      .catch((e) => {
+        console.log('reCAPTCHA v3', e);
+        pending.current = false;
+        setImgCode({
+          value: '',
+          isInvalid: true,
+          errorMsg: t('msg.failed'),
+        });
+        show();
+      })
+      .finally(() => {
+        setExecuting(false);
+      });
+  };
+
+  const handleCaptchaError = (fel: FieldError[] = []) => {
+    console.log('handleCaptchaError', fel);
+    const captchaErr = fel.find((o) => {
+      return o.error_field === 'captcha_code';
+    });
+
+    const ri = refImgCode.current;
+    if (captchaErr) {
+      /**
+       * `imgCode.value` No value but a validation error is received,
+       * indicating that it is the first time the interface has returned a CAPTCHA error,
+       * the token is requested and the submit is retried without showing anything.
+       * Otherwise the token was rejected, e.g. the score is too low, and the visitor can only retry.
+       */
+      if (ri.value) {
+        setImgCode({
+          value: '',
+          isInvalid: true,
+          errorMsg: captchaErr.error_msg,
+        });
+        fetchCaptchaData();
+        show();
+      } else {
+        fetchCaptchaData().then(() => executeAndSubmit());
+      }
+    } else {
+      close();
+    }
+    // Assist business logic in filtering CAPTCHA error messages when necessary
+    return captchaErr;
+  };
+
+  const getCaptcha = () => {
+    const rc = refCaptcha.current;
+    const ri = refImgCode.current;
+    const r = {
+      verify: !!rc?.verify,
+      captcha_id: rc?.captcha_id,
+      captcha_code: ri.value,
+    };
+
+    return r;
+  };
+
+  const resolveCaptchaReq = (req: ImgCodeReq) => {
+    const r = getCaptcha();
+    if (r.verify) {
+      req.captcha_code = r.captcha_code;
+      req.captcha_id = r.captcha_id;
+    }
+  };
+
+  const handleRetry = () => {
+    setStateShow(false);
+    resetImgCode();
+    executeAndSubmit();
+  };
+
+  useEffect(() => {
+    if (autoInitCaptchaData) {
+      fetchCaptchaData();
+    }
+  }, []);
+
+  useLayoutEffect(() => {
+    refImgCode.current = imgCode;
+    refCaptcha.current = captcha;
+  }, [captcha, imgCode]);
+
+  useEffect(() => {
+    refRoot.current?.render(
+      <Modal
+        style={{ width: '336px', margin: '0 auto', right: 0 }}
+        title="Captcha"
+        show={stateShow}
+        onHide={() => close()}
+        centered>
+        <Modal.Header closeButton>
+          <Modal.Title as="h5">{t('title')}</Modal.Title>
+        </Modal.Header>
+        <Modal.Body>
+          <p className="text-danger">{imgCode?.errorMsg || t('msg.failed')}</p>
+          <div className="d-grid">
+            <Button disabled={executing} onClick={handleRetry}>
+              {executing && <Spinner size="sm" animation="border" className="me-2" />}
+              {t('retry')}
+            </Button>
+          </div>
+        </Modal.Body>
+      </Modal>,
+    );
+  });
+
+  const r = {
+    close,
+    show,
+    check: (submitFunc: SubmitCallback) => {
+      if (pending.current) {
+        return false;
+      }
+      refCallback.current = submitFunc;
+      if (captcha?.verify) {
+        executeAndSubmit();
+        return false;
+      }
+      return submitFunc();
+    },
+    getCaptcha,
+    resolveCaptchaReq,
+    fetchCaptchaData,
+    handleCaptchaError,
+  };
+
+  return r;
+};
+
+export default Index;
@@ -0,0 +1,121 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import { useEffect, useRef } from 'react';
+
+declare global {
+  interface Window {
+    hcaptcha?: any;
+  }
+}
+
+const scriptURL = 'https://js.hcaptcha.com/1/api.js?render=explicit';
+
+let scriptPromise: Promise<void> | null = null;
+
+const loadScript = (language?: string) => {
+  if (window.hcaptcha) {
+    return Promise.resolve();
+  }
+  if (!scriptPromise) {
+    scriptPromise = new Promise<void>((resolve, reject) => {
+      const script = document.createElement('script');
+      script.src = language ? `${scriptURL}&hl=${language}` : scriptURL;
+      script.async = true;
+      script.onload = () => resolve();
+      script.onerror = () => {
+        scriptPromise = null;
+        reject(new Error('load hcaptcha script failed'));
+      };
+      document.head.appendChild(script);
+    });
+  }
+  return scriptPromise;
+};
+
+export interface HCaptchaProps {
+  sitekey: string;
+  theme?: string;
+  size?: string;
+  language?: string;
+  className?: string;
+  onVerify: (token: string) => void;
+  onExpire?: () => void;
+  onError?: () => void;
+}
+
+export const HCaptcha = ({
+  sitekey,
+  theme,
+  size,
+  language,
+  className,
+  onVerify,
+  onExpire,
+  onError,
+}: HCaptchaProps) => {
+  const refContainer = useRef<HTMLDivElement | null>(null);
+  const refCallbacks = useRef({ onVerify, onExpire, onError });
+  refCallbacks.current = { onVerify, onExpire, onError };
+
+  useEffect(() => {
+    let widgetId: string | undefined;
+    let canceled = false;
+    loadScript(language)
+      .then(() => {
+        if (canceled || !refContainer.current) {
+          return;
+        }
+        widgetId = window.hcaptcha.render(refContainer.current, {
+          sitekey,
+          theme: theme || 'light',
+          size: size || 'normal',
+          hl: language || undefined,
+          callback: (token: string) => refCallbacks.current.onVerify(token),
+          'expired-callback': () => refCallbacks.current.onExpire?.(),
+          'chalexpired-callback': () => refCallbacks.current.onExpire?.(),
+          'error-callback': () => refCallbacks.current.onError?.(),
+        });
+        // the invisible widget has no checkbox, the challenge is opened right away
+        if (size === 'invisible') {
+          window.hcaptcha.execute(widgetId);
+        }
+      })
+      .catch((e) => {
+        console.log('hCaptcha', e);
+        refCallbacks.current.onError?.();
+      });
+    return () => {
+      canceled = true;
+      if (widgetId !== undefined) {
+        window.hcaptcha?.remove(widgetId);
+      }
+    };
+  }, [sitekey, theme, size, language]);
+
+  return <div ref={refContainer} className={className} />;
+};
+
+const Index = () => {
+  return (
+    <div>hcaptcha</div>
+  );
+};
+
+export default Index;
@@ -0,0 +1,39 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+/**
+ * @description: System language conversion to the language format of hCaptcha
+ * @link: https://docs.hcaptcha.com/languages
+ */
+export const languageKeys = {
+  de_DE: 'de',
+  en_US: 'en',
+  es_ES: 'es',
+  fr_FR: 'fr',
+  it_IT: 'it',
+  ja_JP: 'ja',
+  ko_KR: 'ko',
+  pt_BR: 'pt-BR',
+  pt_PT: 'pt',
+  ru_RU: 'ru',
+  tr_TR: 'tr',
+  vi_VN: 'vi',
+  zh_CN: 'zh-CN',
+  zh_TW: 'zh-TW',
+};
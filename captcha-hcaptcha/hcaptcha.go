@@ -0,0 +1,290 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package hcaptcha
+
+import (
+	"embed"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/apache/answer-plugins/captcha-hcaptcha/i18n"
+	"github.com/apache/answer-plugins/util"
+	"github.com/apache/answer/plugin"
+	"github.com/segmentfault/pacman/log"
+)
+
+const (
+	defaultSiteVerifyEndpoint = "https://api.hcaptcha.com/siteverify"
+
+	ThemeLight = "light"
+	ThemeDark  = "dark"
+
+	SizeNormal    = "normal"
+	SizeCompact   = "compact"
+	SizeInvisible = "invisible"
+)
+
+//go:embed  info.yaml
+var Info embed.FS
+
+type Captcha struct {
+	Config *CaptchaConfig
+}
+
+type CaptchaConfig struct {
+	SiteKey            string `json:"site_key"`
+	SecretKey          string `json:"secret_key"`
+	SiteVerifyEndpoint string `json:"site_verify_endpoint"`
+	// Hostnames is the comma separated allowlist of the hostname the captcha was solved on,
+	// empty means the host of the site url
+	Hostnames string `json:"hostnames"`
+	Theme     string `json:"theme"`
+	Size      string `json:"size"`
+}
+
+type HCaptchaResponse struct {
+	Success     bool     `json:"success"`
+	ErrorCodes  []string `json:"error-codes"`
+	ChallengeTS string   `json:"challenge_ts"`
+	Hostname    string   `json:"hostname"`
+	// SiteKey is only returned by some plans, it is checked when present
+	SiteKey string `json:"sitekey"`
+}
+
+func init() {
+	plugin.Register(&Captcha{
+		Config: &CaptchaConfig{},
+	})
+}
+
+func (c *Captcha) Info() plugin.Info {
+	info := &util.Info{}
+	info.GetInfo(Info)
+
+	return plugin.Info{
+		Name:        plugin.MakeTranslator(i18n.InfoName),
+		SlugName:    info.SlugName,
+		Description: plugin.MakeTranslator(i18n.InfoDescription),
+		Author:      info.Author,
+		Version:     info.Version,
+		Link:        info.Link,
+	}
+}
+
+func (c *Captcha) GetConfig() (config string) {
+	theme := c.Config.Theme
+	if theme != ThemeDark {
+		theme = ThemeLight
+	}
+	size := c.Config.Size
+	if size != SizeCompact && size != SizeInvisible {
+		size = SizeNormal
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"key":   c.Config.SiteKey,
+		"theme": theme,
+		"size":  size,
+	})
+	return string(data)
+}
+
+func (c *Captcha) Create() (captcha, code string) {
+	return "", ""
+}
+
+func (c *Captcha) Verify(captcha, userInput string) (pass bool) {
+	if len(userInput) == 0 {
+		return false
+	}
+	cli := &http.Client{}
+	cli.Timeout = 10 * time.Second
+	siteVerifyEndpoint := c.Config.SiteVerifyEndpoint
+	if siteVerifyEndpoint == "" {
+		siteVerifyEndpoint = defaultSiteVerifyEndpoint
+	}
+	form := map[string][]string{
+		"secret":   {c.Config.SecretKey},
+		"response": {userInput},
+	}
+	// hCaptcha rejects tokens solved for another site key when it is sent
+	if len(c.Config.SiteKey) > 0 {
+		form["sitekey"] = []string{c.Config.SiteKey}
+	}
+	resp, err := cli.PostForm(siteVerifyEndpoint, form)
+	if err != nil {
+		log.Errorf("verify captcha error %s", err.Error())
+		return false
+	}
+	defer resp.Body.Close()
+	all, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Errorf("verify captcha, read body error %s", err.Error())
+		return false
+	}
+	r := &HCaptchaResponse{}
+	if err = json.Unmarshal(all, r); err != nil {
+		log.Errorf("verify captcha, parse response error %s, response is %s", err.Error(), string(all))
+		return false
+	}
+	if !r.Success {
+		log.Debugf("user input is wrong, error codes %v", r.ErrorCodes)
+		return false
+	}
+	if len(r.SiteKey) > 0 && len(c.Config.SiteKey) > 0 && r.SiteKey != c.Config.SiteKey {
+		log.Debugf("captcha site key mismatch, got %s", r.SiteKey)
+		return false
+	}
+	if hostnames := c.allowedHostnames(); len(hostnames) > 0 && !matchHostname(hostnames, r.Hostname) {
+		log.Debugf("captcha hostname %s is not allowed", r.Hostname)
+		return false
+	}
+	return true
+}
+
+// allowedHostnames returns the configured hostnames, or the host of the site url by default
+func (c *Captcha) allowedHostnames() (hostnames []string) {
+	for _, hostname := range strings.Split(c.Config.Hostnames, ",") {
+		hostname = strings.ToLower(strings.TrimSpace(hostname))
+		if len(hostname) > 0 {
+			hostnames = append(hostnames, hostname)
+		}
+	}
+	if len(hostnames) > 0 {
+		return hostnames
+	}
+	if u, err := url.Parse(plugin.SiteURL()); err == nil && len(u.Hostname()) > 0 {
+		return []string{strings.ToLower(u.Hostname())}
+	}
+	return nil
+}
+
+// matchHostname reports whether the hostname is allowed, "*.example.com" matches any subdomain
+func matchHostname(allowed []string, hostname string) bool {
+	hostname = strings.ToLower(hostname)
+	for _, pattern := range allowed {
+		if pattern == hostname {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(pattern, "*"); ok && strings.HasPrefix(suffix, ".") &&
+			strings.HasSuffix(hostname, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Captcha) ConfigFields() []plugin.ConfigField {
+	return []plugin.ConfigField{
+		{
+			Name:        "site_key",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigSiteKeyTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigSiteKeyDescription),
+			Required:    true,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.SiteKey,
+		},
+		{
+			Name:        "secret_key",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigSecretKeyTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigSecretKeyDescription),
+			Required:    true,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.SecretKey,
+		},
+		{
+			Name:        "site_verify_endpoint",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigSiteVerifyEndpointTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigSiteVerifyEndpointDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.SiteVerifyEndpoint,
+		},
+		{
+			Name:        "hostnames",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigHostnamesTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigHostnamesDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.Hostnames,
+		},
+		{
+			Name:     "theme",
+			Type:     plugin.ConfigTypeSelect,
+			Title:    plugin.MakeTranslator(i18n.ConfigThemeTitle),
+			Required: false,
+			Options: []plugin.ConfigFieldOption{
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigThemeOptionsLight),
+					Value: ThemeLight,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigThemeOptionsDark),
+					Value: ThemeDark,
+				},
+			},
+			Value: c.Config.Theme,
+		},
+		{
+			Name:        "size",
+			Type:        plugin.ConfigTypeSelect,
+			Title:       plugin.MakeTranslator(i18n.ConfigSizeTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigSizeDescription),
+			Required:    false,
+			Options: []plugin.ConfigFieldOption{
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigSizeOptionsNormal),
+					Value: SizeNormal,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigSizeOptionsCompact),
+					Value: SizeCompact,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigSizeOptionsInvisible),
+					Value: SizeInvisible,
+				},
+			},
+			Value: c.Config.Size,
+		},
+	}
+}
+
+func (c *Captcha) ConfigReceiver(config []byte) error {
+	conf := &CaptchaConfig{}
+	_ = json.Unmarshal(config, conf)
+	c.Config = conf
+	return nil
+}
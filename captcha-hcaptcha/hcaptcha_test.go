@@ -0,0 +1,120 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package hcaptcha
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCaptcha_Verify(t *testing.T) {
+	tests := []struct {
+		name      string
+		hostnames string
+		response  *HCaptchaResponse
+		status    int
+		pass      bool
+	}{
+		{
+			name:     "success",
+			response: &HCaptchaResponse{Success: true, Hostname: "answer.example.com"},
+			pass:     true,
+		},
+		{
+			name:     "failure",
+			response: &HCaptchaResponse{Success: false, ErrorCodes: []string{"invalid-input-response"}},
+			pass:     false,
+		},
+		{
+			name:      "hostname allowed",
+			hostnames: "other.example.com, Answer.Example.com",
+			response:  &HCaptchaResponse{Success: true, Hostname: "answer.example.com"},
+			pass:      true,
+		},
+		{
+			name:      "hostname not allowed",
+			hostnames: "answer.example.com",
+			response:  &HCaptchaResponse{Success: true, Hostname: "evil.example.org"},
+			pass:      false,
+		},
+		{
+			name:      "wildcard hostname",
+			hostnames: "*.example.com",
+			response:  &HCaptchaResponse{Success: true, Hostname: "answer.example.com"},
+			pass:      true,
+		},
+		{
+			name:      "wildcard does not match suffix without dot",
+			hostnames: "*.example.com",
+			response:  &HCaptchaResponse{Success: true, Hostname: "badexample.com"},
+			pass:      false,
+		},
+		{
+			name:     "site key matches",
+			response: &HCaptchaResponse{Success: true, SiteKey: "site"},
+			pass:     true,
+		},
+		{
+			name:     "site key mismatch",
+			response: &HCaptchaResponse{Success: true, SiteKey: "other"},
+			pass:     false,
+		},
+		{
+			name:   "server error",
+			status: http.StatusInternalServerError,
+			pass:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.PostFormValue("secret") != "secret" || r.PostFormValue("response") != "token" ||
+					r.PostFormValue("sitekey") != "site" {
+					t.Errorf("unexpected form %v", r.PostForm)
+				}
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+					return
+				}
+				_ = json.NewEncoder(w).Encode(tt.response)
+			}))
+			defer server.Close()
+
+			c := &Captcha{Config: &CaptchaConfig{
+				SiteKey:            "site",
+				SecretKey:          "secret",
+				SiteVerifyEndpoint: server.URL,
+				Hostnames:          tt.hostnames,
+			}}
+			if pass := c.Verify("", "token"); pass != tt.pass {
+				t.Errorf("Verify() = %v, want %v", pass, tt.pass)
+			}
+		})
+	}
+}
+
+func TestCaptcha_VerifyEmptyInput(t *testing.T) {
+	c := &Captcha{Config: &CaptchaConfig{}}
+	if c.Verify("", "") {
+		t.Error("Verify() passed an empty response")
+	}
+}
@@ -0,0 +1,41 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package i18n
+
+const (
+	InfoName                            = "plugin.hcaptcha_captcha.backend.info.name"
+	InfoDescription                     = "plugin.hcaptcha_captcha.backend.info.description"
+	ConfigSiteKeyTitle                  = "plugin.hcaptcha_captcha.backend.config.site_key.title"
+	ConfigSiteKeyDescription            = "plugin.hcaptcha_captcha.backend.config.site_key.description"
+	ConfigSecretKeyTitle                = "plugin.hcaptcha_captcha.backend.config.secret_key.title"
+	ConfigSecretKeyDescription          = "plugin.hcaptcha_captcha.backend.config.secret_key.description"
+	ConfigSiteVerifyEndpointTitle       = "plugin.hcaptcha_captcha.backend.config.site_verify_endpoint.title"
+	ConfigSiteVerifyEndpointDescription = "plugin.hcaptcha_captcha.backend.config.site_verify_endpoint.description"
+	ConfigHostnamesTitle                = "plugin.hcaptcha_captcha.backend.config.hostnames.title"
+	ConfigHostnamesDescription          = "plugin.hcaptcha_captcha.backend.config.hostnames.description"
+	ConfigThemeTitle                    = "plugin.hcaptcha_captcha.backend.config.theme.title"
+	ConfigThemeOptionsLight             = "plugin.hcaptcha_captcha.backend.config.theme.options.light"
+	ConfigThemeOptionsDark              = "plugin.hcaptcha_captcha.backend.config.theme.options.dark"
+	ConfigSizeTitle                     = "plugin.hcaptcha_captcha.backend.config.size.title"
+	ConfigSizeDescription               = "plugin.hcaptcha_captcha.backend.config.size.description"
+	ConfigSizeOptionsNormal             = "plugin.hcaptcha_captcha.backend.config.size.options.normal"
+	ConfigSizeOptionsCompact            = "plugin.hcaptcha_captcha.backend.config.size.options.compact"
+	ConfigSizeOptionsInvisible          = "plugin.hcaptcha_captcha.backend.config.size.options.invisible"
+)
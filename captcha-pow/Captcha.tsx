@@ -0,0 +1,105 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import type { Challenge, Solution } from './interface';
+
+/**
+ * The worker tries every number until the hash of salt + number is the challenge.
+ * It is inlined so the plugin works without serving a separate worker file.
+ */
+const workerSource = `
+self.onmessage = async (e) => {
+  const { challenge, salt, maxnumber } = e.data;
+  const encoder = new TextEncoder();
+  const start = Date.now();
+  for (let n = 0; n <= maxnumber; n += 1) {
+    const buf = await crypto.subtle.digest('SHA-256', encoder.encode(salt + n));
+    const hex = Array.from(new Uint8Array(buf))
+      .map((b) => b.toString(16).padStart(2, '0'))
+      .join('');
+    if (hex === challenge) {
+      self.postMessage({ number: n, took: Date.now() - start });
+      return;
+    }
+  }
+  self.postMessage(null);
+};
+`;
+
+/**
+ * Solves the challenge in a Web Worker, so the page stays responsive.
+ * Call `abort` to stop the worker when the solution is no longer needed.
+ */
+export const solveChallenge = (challenge: Challenge) => {
+  const url = URL.createObjectURL(
+    new Blob([workerSource], { type: 'text/javascript' }),
+  );
+  const worker = new Worker(url);
+  const abort = () => {
+    worker.terminate();
+    URL.revokeObjectURL(url);
+  };
+  const promise = new Promise<Solution>((resolve, reject) => {
+    worker.onmessage = (e) => {
+      abort();
+      if (e.data) {
+        resolve(e.data);
+      } else {
+        reject(new Error('challenge has no solution'));
+      }
+    };
+    worker.onerror = (e) => {
+      abort();
+      reject(e);
+    };
+    worker.postMessage(challenge);
+  });
+  return { promise, abort };
+};
+
+/**
+ * Encodes the solution as the captcha code expected by the server
+ */
+export const encodePayload = (challenge: Challenge, solution: Solution) => {
+  return btoa(
+    JSON.stringify({
+      algorithm: challenge.algorithm,
+      challenge: challenge.challenge,
+      number: solution.number,
+      salt: challenge.salt,
+      signature: challenge.signature,
+    }),
+  );
+};
+
+export const parseChallenge = (captcha: string): Challenge | null => {
+  try {
+    return JSON.parse(captcha);
+  } catch (e) {
+    return null;
+  }
+};
+
+const Index = () => {
+  return (
+    <div>pow</div>
+  );
+};
+
+export default Index;
@@ -0,0 +1,173 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package pow
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const algorithm = "SHA-256"
+
+// Challenge is sent to the frontend, which has to find the number that hashes with the salt to the challenge.
+// The signature covers both the salt and the challenge, so it proves both were issued by this server,
+// including the expiry carried by the salt.
+type Challenge struct {
+	Algorithm string `json:"algorithm"`
+	Challenge string `json:"challenge"`
+	MaxNumber int64  `json:"maxnumber"`
+	Salt      string `json:"salt"`
+	Signature string `json:"signature"`
+}
+
+// Payload is the solution posted by the frontend as base64 encoded json
+type Payload struct {
+	Algorithm string `json:"algorithm"`
+	Challenge string `json:"challenge"`
+	Number    int64  `json:"number"`
+	Salt      string `json:"salt"`
+	Signature string `json:"signature"`
+}
+
+// newChallenge picks a random number up to maxNumber and hashes it with a random salt carrying the expiry
+func newChallenge(key []byte, maxNumber int64, expireAt time.Time) (*Challenge, error) {
+	salt := make([]byte, 12)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	number, err := rand.Int(rand.Reader, big.NewInt(maxNumber+1))
+	if err != nil {
+		return nil, err
+	}
+	c := &Challenge{
+		Algorithm: algorithm,
+		MaxNumber: maxNumber,
+		Salt:      hex.EncodeToString(salt) + "?expires=" + strconv.FormatInt(expireAt.Unix(), 10),
+	}
+	c.Challenge = hashChallenge(c.Salt, number.Int64())
+	c.Signature = sign(key, c.Salt, c.Challenge)
+	return c, nil
+}
+
+// verifySolution checks the payload solves the challenge, was signed with the key and is not expired
+func verifySolution(key []byte, payload *Payload, now time.Time) error {
+	if payload.Algorithm != algorithm {
+		return fmt.Errorf("unsupported algorithm %s", payload.Algorithm)
+	}
+	// the hash of salt + number alone can't bind the salt, digits of the number could be moved to the expiry
+	if !hmac.Equal([]byte(sign(key, payload.Salt, payload.Challenge)), []byte(payload.Signature)) {
+		return fmt.Errorf("invalid signature")
+	}
+	expireAt, err := saltExpiry(payload.Salt)
+	if err != nil {
+		return err
+	}
+	if now.After(expireAt) {
+		return fmt.Errorf("challenge expired at %s", expireAt)
+	}
+	if payload.Number < 0 || subtle.ConstantTimeCompare(
+		[]byte(hashChallenge(payload.Salt, payload.Number)), []byte(payload.Challenge)) != 1 {
+		return fmt.Errorf("wrong solution")
+	}
+	return nil
+}
+
+// decodePayload parses the base64 encoded json posted by the frontend
+func decodePayload(userInput string) (*Payload, error) {
+	data, err := base64.StdEncoding.DecodeString(userInput)
+	if err != nil {
+		return nil, err
+	}
+	payload := &Payload{}
+	if err = json.Unmarshal(data, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+func saltExpiry(salt string) (time.Time, error) {
+	_, query, _ := strings.Cut(salt, "?")
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return time.Time{}, err
+	}
+	expires, err := strconv.ParseInt(values.Get("expires"), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiry in salt %s", salt)
+	}
+	return time.Unix(expires, 0), nil
+}
+
+func hashChallenge(salt string, number int64) string {
+	sum := sha256.Sum256([]byte(salt + strconv.FormatInt(number, 10)))
+	return hex.EncodeToString(sum[:])
+}
+
+// sign signs the salt and the challenge, the challenge is a fixed length hex hash after the separator
+func sign(key []byte, salt, challenge string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(salt))
+	mac.Write([]byte{0})
+	mac.Write([]byte(challenge))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// spentChallenges remembers the solved challenges until they expire, so a solution can only be used once
+type spentChallenges struct {
+	mu        sync.Mutex
+	spent     map[string]time.Time
+	lastSweep time.Time
+}
+
+func newSpentChallenges() *spentChallenges {
+	return &spentChallenges{spent: make(map[string]time.Time)}
+}
+
+// spend marks the challenge as used and reports whether it was unused before
+func (s *spentChallenges) spend(challenge string, expireAt time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if now.Sub(s.lastSweep) > time.Minute {
+		for key, at := range s.spent {
+			if now.After(at) {
+				delete(s.spent, key)
+			}
+		}
+		s.lastSweep = now
+	}
+	if _, ok := s.spent[challenge]; ok {
+		return false
+	}
+	s.spent[challenge] = expireAt
+	return true
+}
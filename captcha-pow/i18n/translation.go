@@ -0,0 +1,31 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package i18n
+
+const (
+	InfoName                    = "plugin.pow_captcha.backend.info.name"
+	InfoDescription             = "plugin.pow_captcha.backend.info.description"
+	ConfigDifficultyTitle       = "plugin.pow_captcha.backend.config.difficulty.title"
+	ConfigDifficultyDescription = "plugin.pow_captcha.backend.config.difficulty.description"
+	ConfigExpiryTitle           = "plugin.pow_captcha.backend.config.expiry.title"
+	ConfigExpiryDescription     = "plugin.pow_captcha.backend.config.expiry.description"
+	ConfigHMACKeyTitle          = "plugin.pow_captcha.backend.config.hmac_key.title"
+	ConfigHMACKeyDescription    = "plugin.pow_captcha.backend.config.hmac_key.description"
+)
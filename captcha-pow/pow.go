@@ -0,0 +1,192 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package pow
+
+import (
+	"crypto/rand"
+	"embed"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/apache/answer-plugins/captcha-pow/i18n"
+	"github.com/apache/answer-plugins/util"
+	"github.com/apache/answer/plugin"
+	"github.com/segmentfault/pacman/log"
+)
+
+const (
+	defaultDifficulty = 100000
+	defaultExpiry     = 300
+	// maxExpiry is how long answer keeps the issued challenge, a longer expiry makes no difference
+	maxExpiry = 360
+)
+
+//go:embed  info.yaml
+var Info embed.FS
+
+type Captcha struct {
+	Config *CaptchaConfig
+	// key signs the challenges, it is random unless a hmac key is configured
+	key   []byte
+	spent *spentChallenges
+}
+
+type CaptchaConfig struct {
+	// Difficulty is the largest number the visitor may have to try, the work is half of it on average
+	Difficulty string `json:"difficulty"`
+	// Expiry is the number of seconds a challenge can be solved in
+	Expiry string `json:"expiry"`
+	// HMACKey must be the same on all instances serving the site
+	HMACKey string `json:"hmac_key"`
+}
+
+func init() {
+	plugin.Register(&Captcha{
+		Config: &CaptchaConfig{},
+		key:    randomKey(),
+		spent:  newSpentChallenges(),
+	})
+}
+
+func (c *Captcha) Info() plugin.Info {
+	info := &util.Info{}
+	info.GetInfo(Info)
+
+	return plugin.Info{
+		Name:        plugin.MakeTranslator(i18n.InfoName),
+		SlugName:    info.SlugName,
+		Description: plugin.MakeTranslator(i18n.InfoDescription),
+		Author:      info.Author,
+		Version:     info.Version,
+		Link:        info.Link,
+	}
+}
+
+func (c *Captcha) GetConfig() (config string) {
+	return ""
+}
+
+// Create issues a signed challenge, the challenge hash is kept by answer to bind the solution to this captcha
+func (c *Captcha) Create() (captcha, code string) {
+	expiry := positiveIntOrDefault(c.Config.Expiry, defaultExpiry)
+	if expiry > maxExpiry {
+		expiry = maxExpiry
+	}
+	challenge, err := newChallenge(c.signingKey(), int64(positiveIntOrDefault(c.Config.Difficulty, defaultDifficulty)),
+		time.Now().Add(time.Duration(expiry)*time.Second))
+	if err != nil {
+		log.Errorf("create challenge error %s", err.Error())
+		return "", ""
+	}
+	data, _ := json.Marshal(challenge)
+	return string(data), challenge.Challenge
+}
+
+func (c *Captcha) Verify(captcha, userInput string) (pass bool) {
+	if len(captcha) == 0 || len(userInput) == 0 {
+		return false
+	}
+	payload, err := decodePayload(userInput)
+	if err != nil {
+		log.Debugf("decode captcha payload error %s", err.Error())
+		return false
+	}
+	if payload.Challenge != captcha {
+		log.Debugf("captcha payload solves another challenge")
+		return false
+	}
+	if err = verifySolution(c.signingKey(), payload, time.Now()); err != nil {
+		log.Debugf("verify captcha error %s", err.Error())
+		return false
+	}
+	expireAt, _ := saltExpiry(payload.Salt)
+	if !c.spent.spend(payload.Challenge, expireAt) {
+		log.Debugf("captcha challenge is already used")
+		return false
+	}
+	return true
+}
+
+func (c *Captcha) signingKey() []byte {
+	if len(c.Config.HMACKey) > 0 {
+		return []byte(c.Config.HMACKey)
+	}
+	return c.key
+}
+
+func (c *Captcha) ConfigFields() []plugin.ConfigField {
+	return []plugin.ConfigField{
+		{
+			Name:        "difficulty",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigDifficultyTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigDifficultyDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.Difficulty,
+		},
+		{
+			Name:        "expiry",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigExpiryTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigExpiryDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.Expiry,
+		},
+		{
+			Name:        "hmac_key",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigHMACKeyTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigHMACKeyDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypePassword,
+			},
+			Value: c.Config.HMACKey,
+		},
+	}
+}
+
+func (c *Captcha) ConfigReceiver(config []byte) error {
+	conf := &CaptchaConfig{}
+	_ = json.Unmarshal(config, conf)
+	c.Config = conf
+	return nil
+}
+
+func randomKey() []byte {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	return key
+}
+
+func positiveIntOrDefault(value string, defaultValue int) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return defaultValue
+	}
+	return n
+}
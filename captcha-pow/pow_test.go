@@ -0,0 +1,158 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package pow
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// solve finds the number like the frontend worker does
+func solve(t *testing.T, challenge *Challenge) *Payload {
+	for n := int64(0); n <= challenge.MaxNumber; n++ {
+		if hashChallenge(challenge.Salt, n) == challenge.Challenge {
+			return &Payload{
+				Algorithm: challenge.Algorithm,
+				Challenge: challenge.Challenge,
+				Number:    n,
+				Salt:      challenge.Salt,
+				Signature: challenge.Signature,
+			}
+		}
+	}
+	t.Fatal("challenge has no solution")
+	return nil
+}
+
+func encode(payload *Payload) string {
+	data, _ := json.Marshal(payload)
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func newTestCaptcha() *Captcha {
+	return &Captcha{
+		Config: &CaptchaConfig{Difficulty: "1000"},
+		key:    randomKey(),
+		spent:  newSpentChallenges(),
+	}
+}
+
+func TestCaptcha_Verify(t *testing.T) {
+	c := newTestCaptcha()
+	captcha, code := c.Create()
+	challenge := &Challenge{}
+	if err := json.Unmarshal([]byte(captcha), challenge); err != nil {
+		t.Fatal(err)
+	}
+	if challenge.MaxNumber != 1000 || code != challenge.Challenge {
+		t.Fatalf("Create() = %s, %s", captcha, code)
+	}
+	payload := solve(t, challenge)
+
+	wrong := *payload
+	wrong.Number++
+	if c.Verify(code, encode(&wrong)) {
+		t.Error("Verify() passed a wrong number")
+	}
+	forged := *payload
+	forged.Signature = sign([]byte("other"), forged.Salt, forged.Challenge)
+	if c.Verify(code, encode(&forged)) {
+		t.Error("Verify() passed a challenge signed with another key")
+	}
+	if c.Verify("other", encode(payload)) {
+		t.Error("Verify() passed the solution of another captcha")
+	}
+	if c.Verify(code, "not base64") {
+		t.Error("Verify() passed an invalid payload")
+	}
+	if !c.Verify(code, encode(payload)) {
+		t.Fatal("Verify() = false, want true")
+	}
+	if c.Verify(code, encode(payload)) {
+		t.Error("Verify() passed the same solution twice")
+	}
+}
+
+func TestCaptcha_VerifyConfiguredKey(t *testing.T) {
+	c := newTestCaptcha()
+	c.Config.HMACKey = "shared"
+	_, code := c.Create()
+	other := newTestCaptcha()
+	other.Config.HMACKey = "shared"
+
+	captcha, _ := c.Create()
+	challenge := &Challenge{}
+	_ = json.Unmarshal([]byte(captcha), challenge)
+	if !other.Verify(challenge.Challenge, encode(solve(t, challenge))) {
+		t.Error("Verify() failed on another instance with the same key")
+	}
+	if code == challenge.Challenge {
+		t.Error("Create() returned the same challenge twice")
+	}
+}
+
+func TestVerifySolution_Expired(t *testing.T) {
+	key := randomKey()
+	challenge, err := newChallenge(key, 10, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := solve(t, challenge)
+	if err = verifySolution(key, payload, time.Now()); err != nil {
+		t.Errorf("verifySolution() = %v", err)
+	}
+	if err = verifySolution(key, payload, time.Now().Add(2*time.Minute)); err == nil {
+		t.Error("verifySolution() passed an expired challenge")
+	}
+	tampered := *payload
+	tampered.Salt = strings.Replace(tampered.Salt, "?expires=", "?expires=9", 1)
+	if err = verifySolution(key, &tampered, time.Now()); err == nil {
+		t.Error("verifySolution() passed a challenge with a changed expiry")
+	}
+}
+
+func TestVerifySolution_MovedDigits(t *testing.T) {
+	key := randomKey()
+	salt := "0123456789abcdef?expires=" + strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10)
+	challenge := hashChallenge(salt, 12345)
+	payload := &Payload{
+		Algorithm: algorithm,
+		Challenge: challenge,
+		Number:    12345,
+		Salt:      salt,
+		Signature: sign(key, salt, challenge),
+	}
+	if err := verifySolution(key, payload, time.Now()); err != nil {
+		t.Fatalf("verifySolution() = %v", err)
+	}
+	// salt + "1" + "2345" hashes like salt + "12345" but expires ten times later
+	forged := *payload
+	forged.Salt, forged.Number = salt+"1", 2345
+	if hashChallenge(forged.Salt, forged.Number) != challenge {
+		t.Fatal("moved digits do not hash to the challenge")
+	}
+	if err := verifySolution(key, &forged, time.Now()); err == nil {
+		t.Error("verifySolution() passed a salt with digits moved from the number")
+	}
+}
@@ -0,0 +1,123 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import { useEffect, useRef } from 'react';
+
+declare global {
+  interface Window {
+    turnstile?: any;
+  }
+}
+
+const scriptURL =
+  'https://challenges.cloudflare.com/turnstile/v0/api.js?render=explicit';
+
+let scriptPromise: Promise<void> | null = null;
+
+const loadScript = () => {
+  if (window.turnstile) {
+    return Promise.resolve();
+  }
+  if (!scriptPromise) {
+    scriptPromise = new Promise<void>((resolve, reject) => {
+      const script = document.createElement('script');
+      script.src = scriptURL;
+      script.async = true;
+      script.onload = () => resolve();
+      script.onerror = () => {
+        scriptPromise = null;
+        reject(new Error('load turnstile script failed'));
+      };
+      document.head.appendChild(script);
+    });
+  }
+  return scriptPromise;
+};
+
+export interface TurnstileProps {
+  sitekey: string;
+  action?: string;
+  cData?: string;
+  appearance?: string;
+  theme?: string;
+  language?: string;
+  className?: string;
+  onVerify: (token: string) => void;
+  onExpire?: () => void;
+  onError?: () => void;
+}
+
+export const Turnstile = ({
+  sitekey,
+  action,
+  cData,
+  appearance,
+  theme,
+  language,
+  className,
+  onVerify,
+  onExpire,
+  onError,
+}: TurnstileProps) => {
+  const refContainer = useRef<HTMLDivElement | null>(null);
+  const refCallbacks = useRef({ onVerify, onExpire, onError });
+  refCallbacks.current = { onVerify, onExpire, onError };
+
+  useEffect(() => {
+    let widgetId: string | undefined;
+    let canceled = false;
+    loadScript()
+      .then(() => {
+        if (canceled || !refContainer.current) {
+          return;
+        }
+        widgetId = window.turnstile.render(refContainer.current, {
+          sitekey,
+          action: action || undefined,
+          cData: cData || undefined,
+          appearance: appearance || 'always',
+          theme: theme || 'auto',
+          language: language || 'auto',
+          callback: (token: string) => refCallbacks.current.onVerify(token),
+          'expired-callback': () => refCallbacks.current.onExpire?.(),
+          'error-callback': () => refCallbacks.current.onError?.(),
+        });
+      })
+      .catch((e) => {
+        console.log('Turnstile', e);
+        refCallbacks.current.onError?.();
+      });
+    return () => {
+      canceled = true;
+      if (widgetId !== undefined) {
+        window.turnstile?.remove(widgetId);
+      }
+    };
+  }, [sitekey, action, cData, appearance, theme, language]);
+
+  return <div ref={refContainer} className={className} />;
+};
+
+const Index = () => {
+  return (
+    <div>turnstile</div>
+  );
+};
+
+export default Index;
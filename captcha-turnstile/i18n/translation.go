@@ -0,0 +1,41 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package i18n
+
+const (
+	InfoName                               = "plugin.turnstile_captcha.backend.info.name"
+	InfoDescription                        = "plugin.turnstile_captcha.backend.info.description"
+	ConfigSiteKeyTitle                     = "plugin.turnstile_captcha.backend.config.site_key.title"
+	ConfigSiteKeyDescription               = "plugin.turnstile_captcha.backend.config.site_key.description"
+	ConfigSecretKeyTitle                   = "plugin.turnstile_captcha.backend.config.secret_key.title"
+	ConfigSecretKeyDescription             = "plugin.turnstile_captcha.backend.config.secret_key.description"
+	ConfigSiteVerifyEndpointTitle          = "plugin.turnstile_captcha.backend.config.site_verify_endpoint.title"
+	ConfigSiteVerifyEndpointDescription    = "plugin.turnstile_captcha.backend.config.site_verify_endpoint.description"
+	ConfigActionTitle                      = "plugin.turnstile_captcha.backend.config.action.title"
+	ConfigActionDescription                = "plugin.turnstile_captcha.backend.config.action.description"
+	ConfigWidgetModeTitle                  = "plugin.turnstile_captcha.backend.config.widget_mode.title"
+	ConfigWidgetModeDescription            = "plugin.turnstile_captcha.backend.config.widget_mode.description"
+	ConfigWidgetModeOptionsAlways          = "plugin.turnstile_captcha.backend.config.widget_mode.options.always"
+	ConfigWidgetModeOptionsInteractionOnly = "plugin.turnstile_captcha.backend.config.widget_mode.options.interaction_only"
+	ConfigThemeTitle                       = "plugin.turnstile_captcha.backend.config.theme.title"
+	ConfigThemeOptionsAuto                 = "plugin.turnstile_captcha.backend.config.theme.options.auto"
+	ConfigThemeOptionsLight                = "plugin.turnstile_captcha.backend.config.theme.options.light"
+	ConfigThemeOptionsDark                 = "plugin.turnstile_captcha.backend.config.theme.options.dark"
+)
@@ -0,0 +1,261 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package turnstile
+
+import (
+	"crypto/rand"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/apache/answer-plugins/captcha-turnstile/i18n"
+	"github.com/apache/answer-plugins/util"
+	"github.com/apache/answer/plugin"
+	"github.com/segmentfault/pacman/log"
+)
+
+const (
+	defaultSiteVerifyEndpoint = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+
+	WidgetModeAlways          = "always"
+	WidgetModeInteractionOnly = "interaction-only"
+
+	ThemeAuto  = "auto"
+	ThemeLight = "light"
+	ThemeDark  = "dark"
+)
+
+//go:embed  info.yaml
+var Info embed.FS
+
+type Captcha struct {
+	Config *CaptchaConfig
+}
+
+type CaptchaConfig struct {
+	SiteKey            string `json:"site_key"`
+	SecretKey          string `json:"secret_key"`
+	SiteVerifyEndpoint string `json:"site_verify_endpoint"`
+	// Action is sent by the widget and must be returned unchanged by siteverify
+	Action string `json:"action"`
+	// WidgetMode is the appearance of the widget, interaction-only keeps it invisible unless a challenge is needed
+	WidgetMode string `json:"widget_mode"`
+	Theme      string `json:"theme"`
+}
+
+type TurnstileResponse struct {
+	Success     bool     `json:"success"`
+	ErrorCodes  []string `json:"error-codes"`
+	ChallengeTS string   `json:"challenge_ts"`
+	Hostname    string   `json:"hostname"`
+	Action      string   `json:"action"`
+	CData       string   `json:"cdata"`
+}
+
+func init() {
+	plugin.Register(&Captcha{
+		Config: &CaptchaConfig{},
+	})
+}
+
+func (c *Captcha) Info() plugin.Info {
+	info := &util.Info{}
+	info.GetInfo(Info)
+
+	return plugin.Info{
+		Name:        plugin.MakeTranslator(i18n.InfoName),
+		SlugName:    info.SlugName,
+		Description: plugin.MakeTranslator(i18n.InfoDescription),
+		Author:      info.Author,
+		Version:     info.Version,
+		Link:        info.Link,
+	}
+}
+
+func (c *Captcha) GetConfig() (config string) {
+	widgetMode := c.Config.WidgetMode
+	if widgetMode != WidgetModeInteractionOnly {
+		widgetMode = WidgetModeAlways
+	}
+	theme := c.Config.Theme
+	if theme != ThemeLight && theme != ThemeDark {
+		theme = ThemeAuto
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"key":         c.Config.SiteKey,
+		"action":      c.Config.Action,
+		"widget_mode": widgetMode,
+		"theme":       theme,
+	})
+	return string(data)
+}
+
+// Create issues a random cdata for every challenge. The widget sends it to Cloudflare,
+// and Verify checks that the token was solved for this challenge.
+func (c *Captcha) Create() (captcha, code string) {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	cdata := hex.EncodeToString(b)
+	return cdata, cdata
+}
+
+func (c *Captcha) Verify(captcha, userInput string) (pass bool) {
+	// without the cdata the captcha is expired or was never created, and the token could be solved for any other
+	if len(captcha) == 0 || len(userInput) == 0 {
+		return false
+	}
+	cli := &http.Client{}
+	cli.Timeout = 10 * time.Second
+	siteVerifyEndpoint := c.Config.SiteVerifyEndpoint
+	if siteVerifyEndpoint == "" {
+		siteVerifyEndpoint = defaultSiteVerifyEndpoint
+	}
+	resp, err := cli.PostForm(siteVerifyEndpoint, map[string][]string{
+		"secret":   {c.Config.SecretKey},
+		"response": {userInput},
+	})
+	if err != nil {
+		log.Errorf("verify captcha error %s", err.Error())
+		return false
+	}
+	defer resp.Body.Close()
+	all, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Errorf("verify captcha, read body error %s", err.Error())
+		return false
+	}
+	r := &TurnstileResponse{}
+	if err = json.Unmarshal(all, r); err != nil {
+		log.Errorf("verify captcha, parse response error %s, response is %s", err.Error(), string(all))
+		return false
+	}
+	if !r.Success {
+		log.Debugf("user input is wrong, error codes %v", r.ErrorCodes)
+		return false
+	}
+	if len(c.Config.Action) > 0 && r.Action != c.Config.Action {
+		log.Debugf("captcha action mismatch, expected %s, got %s", c.Config.Action, r.Action)
+		return false
+	}
+	// challenges created by Create carry a cdata, which must come back from siteverify
+	if r.CData != captcha {
+		log.Debugf("captcha cdata mismatch, expected %s, got %s", captcha, r.CData)
+		return false
+	}
+	return true
+}
+
+func (c *Captcha) ConfigFields() []plugin.ConfigField {
+	return []plugin.ConfigField{
+		{
+			Name:        "site_key",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigSiteKeyTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigSiteKeyDescription),
+			Required:    true,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.SiteKey,
+		},
+		{
+			Name:        "secret_key",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigSecretKeyTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigSecretKeyDescription),
+			Required:    true,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.SecretKey,
+		},
+		{
+			Name:        "site_verify_endpoint",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigSiteVerifyEndpointTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigSiteVerifyEndpointDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.SiteVerifyEndpoint,
+		},
+		{
+			Name:        "action",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigActionTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigActionDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.Action,
+		},
+		{
+			Name:        "widget_mode",
+			Type:        plugin.ConfigTypeSelect,
+			Title:       plugin.MakeTranslator(i18n.ConfigWidgetModeTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigWidgetModeDescription),
+			Required:    false,
+			Options: []plugin.ConfigFieldOption{
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigWidgetModeOptionsAlways),
+					Value: WidgetModeAlways,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigWidgetModeOptionsInteractionOnly),
+					Value: WidgetModeInteractionOnly,
+				},
+			},
+			Value: c.Config.WidgetMode,
+		},
+		{
+			Name:     "theme",
+			Type:     plugin.ConfigTypeSelect,
+			Title:    plugin.MakeTranslator(i18n.ConfigThemeTitle),
+			Required: false,
+			Options: []plugin.ConfigFieldOption{
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigThemeOptionsAuto),
+					Value: ThemeAuto,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigThemeOptionsLight),
+					Value: ThemeLight,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigThemeOptionsDark),
+					Value: ThemeDark,
+				},
+			},
+			Value: c.Config.Theme,
+		},
+	}
+}
+
+func (c *Captcha) ConfigReceiver(config []byte) error {
+	conf := &CaptchaConfig{}
+	_ = json.Unmarshal(config, conf)
+	c.Config = conf
+	return nil
+}
@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package turnstile
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCaptcha_Verify(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   string
+		captcha  string
+		response *TurnstileResponse
+		status   int
+		pass     bool
+	}{
+		{
+			name:     "success",
+			captcha:  "abc",
+			response: &TurnstileResponse{Success: true, CData: "abc"},
+			pass:     true,
+		},
+		{
+			name:     "failure",
+			response: &TurnstileResponse{Success: false, ErrorCodes: []string{"invalid-input-response"}},
+			pass:     false,
+		},
+		{
+			name:     "action matches",
+			action:   "login",
+			captcha:  "abc",
+			response: &TurnstileResponse{Success: true, Action: "login", CData: "abc"},
+			pass:     true,
+		},
+		{
+			name:     "action mismatch",
+			action:   "login",
+			captcha:  "abc",
+			response: &TurnstileResponse{Success: true, Action: "signup", CData: "abc"},
+			pass:     false,
+		},
+		{
+			name:     "cdata matches",
+			captcha:  "abc",
+			response: &TurnstileResponse{Success: true, CData: "abc"},
+			pass:     true,
+		},
+		{
+			name:     "cdata mismatch",
+			captcha:  "abc",
+			response: &TurnstileResponse{Success: true, CData: "other"},
+			pass:     false,
+		},
+		{
+			name:     "expired captcha",
+			response: &TurnstileResponse{Success: true, CData: "abc"},
+			pass:     false,
+		},
+		{
+			name:     "token without cdata",
+			response: &TurnstileResponse{Success: true},
+			pass:     false,
+		},
+		{
+			name:   "server error",
+			status: http.StatusInternalServerError,
+			pass:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.PostFormValue("secret") != "secret" || r.PostFormValue("response") != "token" {
+					t.Errorf("unexpected form %v", r.PostForm)
+				}
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+					return
+				}
+				_ = json.NewEncoder(w).Encode(tt.response)
+			}))
+			defer server.Close()
+
+			c := &Captcha{Config: &CaptchaConfig{
+				SecretKey:          "secret",
+				SiteVerifyEndpoint: server.URL,
+				Action:             tt.action,
+			}}
+			if pass := c.Verify(tt.captcha, "token"); pass != tt.pass {
+				t.Errorf("Verify() = %v, want %v", pass, tt.pass)
+			}
+		})
+	}
+}
+
+func TestCaptcha_Create(t *testing.T) {
+	c := &Captcha{Config: &CaptchaConfig{}}
+	captcha, code := c.Create()
+	if len(captcha) == 0 || captcha != code {
+		t.Errorf("Create() = %q, %q", captcha, code)
+	}
+	if other, _ := c.Create(); other == captcha {
+		t.Error("Create() returned the same cdata twice")
+	}
+}
@@ -22,18 +22,17 @@ package aliyun
 import (
 	"embed"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
-	"io/fs"
 	"net/http"
 	"os"
-	"path/filepath"
+	"slices"
 	"strconv"
-	"strings"
 
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
 	"github.com/apache/answer-plugins/cdn-aliyun/i18n"
 	"github.com/apache/answer-plugins/util"
+	"github.com/apache/answer-plugins/util/cdn"
 	"github.com/apache/answer/plugin"
 	"github.com/apache/answer/ui"
 	"github.com/segmentfault/pacman/log"
@@ -41,7 +40,8 @@ import (
 
 var (
 	staticPath = os.Getenv("ANSWER_STATIC_PATH")
-	enable     = false
+	// staticPrefix switches to the CDN once every static file is uploaded
+	staticPrefix = &cdn.StaticPrefix{}
 )
 
 //go:embed  info.yaml
@@ -50,6 +50,10 @@ var Info embed.FS
 const (
 	// 10MB
 	defaultMaxFileSize int64 = 10 * 1024 * 1024
+	// defaultKeepReleases is the number of previous releases kept by the cleanup
+	defaultKeepReleases = 2
+	// deleteObjectsLimit is the number of objects deleted by a request at most
+	deleteObjectsLimit = 1000
 )
 
 type CDN struct {
@@ -57,33 +61,89 @@ type CDN struct {
 }
 
 type CDNConfig struct {
-	Endpoint        string `json:"endpoint"`
-	BucketName      string `json:"bucket_name"`
-	ObjectKeyPrefix string `json:"object_key_prefix"`
-	AccessKeyID     string `json:"access_key_id"`
-	AccessKeySecret string `json:"access_key_secret"`
-	VisitUrlPrefix  string `json:"visit_url_prefix"`
-	MaxFileSize     string `json:"max_file_size"`
+	Endpoint         string `json:"endpoint"`
+	BucketName       string `json:"bucket_name"`
+	ObjectKeyPrefix  string `json:"object_key_prefix"`
+	AccessKeyID      string `json:"access_key_id"`
+	AccessKeySecret  string `json:"access_key_secret"`
+	VisitUrlPrefix   string `json:"visit_url_prefix"`
+	MaxFileSize      string `json:"max_file_size"`
+	Compression      string `json:"compression"`
+	Versioned        bool   `json:"versioned"`
+	KeepReleases     string `json:"keep_releases"`
+	Cleanup          string `json:"cleanup"`
+	CleanUnversioned bool   `json:"clean_unversioned"`
+	RefreshCache     bool   `json:"refresh_cache"`
+	CDNEndpoint      string `json:"cdn_endpoint"`
 }
 
-type CustomFile struct {
-	file      io.Reader
-	cdnPrefix string
-	old       string
+// ossBucket uploads the static files to an aliyun oss bucket
+type ossBucket struct {
+	bucket *oss.Bucket
 }
 
-func (f *CustomFile) Read(p []byte) (n int, err error) {
-	c := make([]byte, len(p))
-	n, err = f.file.Read(c)
-	if f.old != "" {
-		c = []byte(strings.ReplaceAll(string(c), f.old, "\""+f.cdnPrefix+"/static"))
+func (b *ossBucket) PutObject(object *cdn.Object) error {
+	request := &oss.PutObjectRequest{
+		ObjectKey: object.Key,
+		Reader:    object.Body,
+	}
+	options := []oss.Option{
+		oss.ContentType(object.ContentType),
+		oss.CacheControl(object.CacheControl),
+	}
+	if object.ContentEncoding != "" {
+		options = append(options, oss.ContentEncoding(object.ContentEncoding))
 	}
+	respBody, err := b.bucket.DoPutObject(request, options)
+	if err != nil {
+		var serr oss.ServiceError
+		if errors.As(err, &serr) {
+			return &cdn.StatusError{Status: serr.StatusCode, Err: err}
+		}
+		return err
+	}
+	return respBody.Close()
+}
 
-	n = copy(p, c)
-	return
+func (b *ossBucket) GetObject(key string) ([]byte, error) {
+	body, err := b.bucket.GetObject(key)
+	if err != nil {
+		var serr oss.ServiceError
+		if errors.As(err, &serr) && serr.StatusCode == http.StatusNotFound {
+			return nil, cdn.ErrObjectNotExist
+		}
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+func (b *ossBucket) ListObjects(prefix string) ([]string, error) {
+	var keys []string
+	token := ""
+	for {
+		result, err := b.bucket.ListObjectsV2(oss.Prefix(prefix), oss.ContinuationToken(token))
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range result.Objects {
+			keys = append(keys, object.Key)
+		}
+		if !result.IsTruncated {
+			return keys, nil
+		}
+		token = result.NextContinuationToken
+	}
 }
 
-func (f *CustomFile) Close() error { return nil }
+func (b *ossBucket) DeleteObjects(keys []string) error {
+	for batch := range slices.Chunk(keys, deleteObjectsLimit) {
+		if _, err := b.bucket.DeleteObjects(batch, oss.DeleteObjectsQuiet(true)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
 func init() {
 	plugin.Register(&CDN{
@@ -106,291 +166,124 @@ func (c *CDN) Info() plugin.Info {
 
 // GetStaticPrefix get static prefix
 func (c *CDN) GetStaticPrefix() string {
-	if !enable {
-		return ""
-	}
-	return c.Config.VisitUrlPrefix + c.Config.ObjectKeyPrefix
+	return staticPrefix.Get()
 }
 
-// scanFiles scan all the static files in the build directory
-func (c *CDN) scanFiles() {
-	if staticPath == "" {
-		err := c.scanEmbedFiles("build")
-		if err != nil {
-			enable = false
-			log.Error("failed: scan embed files:", err)
-			return
-		}
-		log.Info("complete: scan embed files")
-		enable = true
-		return
-	}
-
-	err := c.scanStaticPathFiles(staticPath)
+// scanFiles uploads all the static files in the build directory, the static prefix switches to
+// the CDN when all of them are uploaded and the sync is still the latest one
+func (c *CDN) scanFiles(generation int64, config *CDNConfig) {
+	bucket, err := config.bucket()
 	if err != nil {
-		enable = false
-		log.Error("fialed: scan static path files:", err)
-		return
-	}
-	enable = true
-	log.Info("complete: scan static path files")
-}
-
-// scanStaticPathFiles scan static path files
-func (c *CDN) scanStaticPathFiles(fileName string) (err error) {
-	if len(fileName) == 0 {
+		log.Error(plugin.MakeTranslator(i18n.ErrMisStorageConfig), err)
 		return
 	}
-	// scan static path files
-	entry, err := os.ReadDir(fileName)
+	build, err := cdn.BuildFS(ui.Build, staticPath)
 	if err != nil {
-		log.Error("read static dir failed: ", err, fileName)
+		log.Error("failed: open static files:", err)
 		return
 	}
-	for _, info := range entry {
-		if info.IsDir() {
-			err = c.scanStaticPathFiles(filepath.Join(fileName, info.Name()))
-			if err != nil {
-				return
-			}
-			continue
-		}
-
-		var file *os.File
-		filePath := filepath.Join(fileName, info.Name())
-		fi, _ := info.Info()
-		size := fi.Size()
-		file, err = os.Open(filePath)
-		if err != nil {
-			log.Error("open file failed: %v", err)
-			return
-		}
-
-		suffix := staticPath[:1]
-		if suffix != "/" {
-			suffix = ""
-		}
-		filePath = strings.TrimPrefix(filePath, staticPath+suffix)
-
-		// rebuild custom io.Reader
-		ns := strings.Split(info.Name(), ".")
-		if info.Name() == "asset-manifest.json" {
-			err = c.Upload(filePath, c.rebuildReader(file, map[string]string{
-				"\"/static": "",
-			}), size)
-			if err != nil {
-				return
-			}
-			continue
-		}
-		if ns[0] == "main" {
-			ext := strings.ToLower(filepath.Ext(filePath))
-			if ext == ".js" || ext == ".map" {
-				err = c.Upload(filePath, c.rebuildReader(file, map[string]string{
-					"\"static": "",
-					"=\"/\",":  "=\"\",",
-				}), size)
-
-				if err != nil {
-					return
-				}
-				continue
-			}
-
-			if ext == ".css" {
-				err = c.Upload(filePath, c.rebuildReader(file, map[string]string{
-					"url(/static": "url(../../static",
-				}), size)
-
-				if err != nil {
-					return
-				}
-				continue
-			}
-		}
-
-		err = c.Upload(filePath, file, size)
-		if err != nil {
+	keyPrefix := config.ObjectKeyPrefix
+	release := ""
+	if config.Versioned {
+		if release, err = cdn.Release(build); err != nil {
+			log.Error("failed: read static files:", err)
 			return
 		}
+		keyPrefix += release + "/"
 	}
-	return nil
-}
-
-func (c *CDN) scanEmbedFiles(fileName string) (err error) {
-	entry, err := ui.Build.ReadDir(fileName)
+	provider := &ossBucket{bucket: bucket}
+	syncer := &cdn.Syncer{
+		Provider:       provider,
+		VisitURLPrefix: config.VisitUrlPrefix,
+		KeyPrefix:      keyPrefix,
+		MaxFileSize:    config.maxFileSizeLimit(),
+		FileTypes:      plugin.DefaultCDNFileType,
+		Compression:    config.Compression,
+		CheckAvailable: true,
+		Logf:           log.Warnf,
+		Progress: func(uploaded, total int) {
+			log.Infof("upload static files: %d/%d", uploaded, total)
+		},
+	}
+	result, err := syncer.Sync(build)
 	if err != nil {
-		log.Error("read static dir failed: %v", err)
+		log.Error("failed: upload static files:", err)
 		return
 	}
-	for _, info := range entry {
-		if info.IsDir() {
-			err = c.scanEmbedFiles(filepath.Join(fileName, info.Name()))
-			if err != nil {
-				return
-			}
-			continue
-		}
-
-		var file fs.File
-		filePath := filepath.Join(fileName, info.Name())
-		fi, _ := info.Info()
-		size := fi.Size()
-		file, err = ui.Build.Open(filePath)
-		defer file.Close()
-		if err != nil {
-			log.Error("open file failed: %v", err)
-			return
-		}
-
-		filePath = strings.TrimPrefix(filePath, "build/")
-
-		// rebuild custom io.Reader
-		ns := strings.Split(info.Name(), ".")
-		if info.Name() == "asset-manifest.json" {
-			err = c.Upload(filePath, c.rebuildReader(file, map[string]string{
-				"\"/static": "",
-			}), size)
-			if err != nil {
-				return
-			}
-			continue
-		}
-		if ns[0] == "main" {
-			ext := strings.ToLower(filepath.Ext(filePath))
-			if ext == ".js" || ext == ".map" {
-				err = c.Upload(filePath, c.rebuildReader(file, map[string]string{
-					"\"static": "",
-					"=\"/\",":  "=\"\",",
-				}), size)
-				if err != nil {
-					return
-				}
-				continue
-			}
-
-			if ext == ".css" {
-				err = c.Upload(filePath, c.rebuildReader(file, map[string]string{
-					"url(/static": "url(../../static",
-				}), size)
-				if err != nil {
-					return
-				}
-				continue
-			}
-		}
-
-		c.Upload(filePath, file, size)
-	}
-	return nil
-}
-
-func (c *CDN) rebuildReader(file io.Reader, replaceMap map[string]string) io.Reader {
-	var (
-		bufr = make([]byte, 0)
-		res  string
-	)
+	staticPrefix.Complete(generation, config.VisitUrlPrefix+keyPrefix)
+	log.Infof("complete: upload static files, %d uploaded, %d unchanged, %d skipped",
+		result.Uploaded, result.Unchanged, result.Skipped)
 
-	for {
-		buf := make([]byte, 1024)
-		n, err := file.Read(buf)
-		if err != nil {
-			break
-		}
-		bufr = append(bufr, buf[:n]...)
+	if config.RefreshCache && len(result.Changed) > 0 {
+		refreshChanged(config, keyPrefix, result.Changed)
 	}
-
-	res = string(bufr)
-
-	for oldStr, newStr := range replaceMap {
-		if oldStr != "" {
-			if newStr == "" {
-				prefix := c.Config.VisitUrlPrefix + c.Config.ObjectKeyPrefix
-				if prefix[len(prefix)-1:] == "/" {
-					prefix = strings.TrimSuffix(prefix, "/")
-				}
-				newStr = "\"" + prefix + "/static"
-			}
-			res = strings.ReplaceAll(res, oldStr, newStr)
-		}
+	if config.Versioned {
+		cleanReleases(provider, config, release)
 	}
-	return strings.NewReader(res)
 }
 
-func (c *CDN) Upload(filePath string, file io.Reader, size int64) (err error) {
-	client, err := oss.New(c.Config.Endpoint, c.Config.AccessKeyID, c.Config.AccessKeySecret)
+// refreshChanged refreshes the copies of the changed objects cached by the CDN
+func refreshChanged(config *CDNConfig, keyPrefix string, changed []string) {
+	urls, objectType := refreshURLs(config.VisitUrlPrefix, keyPrefix, changed)
+	id, err := refreshObjectCaches(config, urls, objectType)
 	if err != nil {
-		log.Error(plugin.MakeTranslator(i18n.ErrMisStorageConfig), err)
-		return
-	}
-	bucket, err := client.Bucket(c.Config.BucketName)
-	if err != nil {
-		log.Error(plugin.MakeTranslator(i18n.ErrMisStorageConfig), err)
+		log.Error("failed: refresh changed static files:", err)
 		return
 	}
+	log.Infof("complete: refresh changed static files, task %s of %d urls", id, len(urls))
+}
 
-	if !c.CheckFileType(filePath) {
-		log.Error(plugin.MakeTranslator(i18n.ErrUnsupportedFileType), filePath)
-		return
+// cleanReleases records the release and removes the releases before the kept ones
+func cleanReleases(provider cdn.CleanupProvider, config *CDNConfig, release string) {
+	cleaner := &cdn.Cleaner{
+		Provider:         provider,
+		KeyPrefix:        config.ObjectKeyPrefix,
+		Keep:             config.keepReleases(),
+		Mode:             config.Cleanup,
+		Logf:             log.Infof,
+		CleanUnversioned: config.CleanUnversioned,
 	}
-
-	if size > c.maxFileSizeLimit() {
-		log.Error(plugin.MakeTranslator(i18n.ErrOverFileSizeLimit))
+	result, err := cleaner.Clean(release)
+	if err != nil {
+		log.Error("failed: clean up releases:", err)
 		return
 	}
-
-	objectKey := c.createObjectKey(filePath)
-	request := &oss.PutObjectRequest{
-		ObjectKey: objectKey,
-		Reader:    file,
+	if len(result.Removed) == 0 && result.Unversioned == 0 {
+		return
 	}
-	respBody, err := bucket.DoPutObject(request, nil)
-	if err != nil {
-		log.Error(plugin.MakeTranslator(i18n.ErrUploadFileFailed), err)
+	if config.Cleanup == cdn.CleanupDryRun {
+		log.Infof("dry run: clean up releases %v and %d unversioned objects, %d objects would be deleted",
+			result.Removed, result.Unversioned, result.Objects)
 		return
 	}
-	defer respBody.Close()
-	return c.checkCDNAvailable(objectKey)
+	log.Infof("complete: clean up releases %v and %d unversioned objects, %d objects deleted",
+		result.Removed, result.Unversioned, result.Objects)
 }
 
-func (c *CDN) checkCDNAvailable(objectKey string) error {
-	url := c.Config.VisitUrlPrefix + objectKey
-	req, err := http.NewRequest("GET", url, nil)
+func (cfg *CDNConfig) bucket() (*oss.Bucket, error) {
+	client, err := oss.New(cfg.Endpoint, cfg.AccessKeyID, cfg.AccessKeySecret)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer response.Body.Close()
-
-	if response.StatusCode != http.StatusOK {
-		log.Error("check error:", url)
-		return fmt.Errorf("failed to get object, %s", response.Status)
-	}
-	return nil
+	return client.Bucket(cfg.BucketName)
 }
 
-func (c *CDN) createObjectKey(filePath string) string {
-	return c.Config.ObjectKeyPrefix + filePath
-}
-
-func (c *CDN) CheckFileType(filePath string) bool {
-	ext := strings.ToLower(filepath.Ext(filePath))
-	if _, ok := plugin.DefaultCDNFileType[ext]; ok {
-		return true
+func (cfg *CDNConfig) keepReleases() int {
+	if len(cfg.KeepReleases) == 0 {
+		return defaultKeepReleases
 	}
-	return false
+	keep, err := strconv.Atoi(cfg.KeepReleases)
+	if err != nil || keep < 0 {
+		return defaultKeepReleases
+	}
+	return keep
 }
 
-func (c *CDN) maxFileSizeLimit() int64 {
-	if len(c.Config.MaxFileSize) == 0 {
+func (cfg *CDNConfig) maxFileSizeLimit() int64 {
+	if len(cfg.MaxFileSize) == 0 {
 		return defaultMaxFileSize
 	}
-	limit, _ := strconv.Atoi(c.Config.MaxFileSize)
+	limit, _ := strconv.Atoi(cfg.MaxFileSize)
 	if limit <= 0 {
 		return defaultMaxFileSize
 	}
@@ -476,6 +369,99 @@ func (c *CDN) ConfigFields() []plugin.ConfigField {
 			},
 			Value: c.Config.MaxFileSize,
 		},
+		{
+			Name:        "compression",
+			Type:        plugin.ConfigTypeSelect,
+			Title:       plugin.MakeTranslator(i18n.ConfigCompressionTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigCompressionDescription),
+			Required:    false,
+			Options: []plugin.ConfigFieldOption{
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigCompressionOptionsNone),
+					Value: cdn.CompressionNone,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigCompressionOptionsGzip),
+					Value: cdn.CompressionGzip,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigCompressionOptionsVariants),
+					Value: cdn.CompressionVariants,
+				},
+			},
+			Value: c.Config.Compression,
+		},
+		{
+			Name:  "versioned",
+			Type:  plugin.ConfigTypeSwitch,
+			Title: plugin.MakeTranslator(i18n.ConfigVersionedTitle),
+			Value: c.Config.Versioned,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				Label: plugin.MakeTranslator(i18n.ConfigVersionedDescription),
+			},
+		},
+		{
+			Name:        "keep_releases",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigKeepReleasesTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigKeepReleasesDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.KeepReleases,
+		},
+		{
+			Name:        "cleanup",
+			Type:        plugin.ConfigTypeSelect,
+			Title:       plugin.MakeTranslator(i18n.ConfigCleanupTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigCleanupDescription),
+			Required:    false,
+			Options: []plugin.ConfigFieldOption{
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigCleanupOptionsOff),
+					Value: cdn.CleanupOff,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigCleanupOptionsDryRun),
+					Value: cdn.CleanupDryRun,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigCleanupOptionsDelete),
+					Value: cdn.CleanupDelete,
+				},
+			},
+			Value: c.Config.Cleanup,
+		},
+		{
+			Name:  "clean_unversioned",
+			Type:  plugin.ConfigTypeSwitch,
+			Title: plugin.MakeTranslator(i18n.ConfigUnversionedTitle),
+			Value: c.Config.CleanUnversioned,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				Label: plugin.MakeTranslator(i18n.ConfigUnversionedDescription),
+			},
+		},
+		{
+			Name:  "refresh_cache",
+			Type:  plugin.ConfigTypeSwitch,
+			Title: plugin.MakeTranslator(i18n.ConfigRefreshCacheTitle),
+			Value: c.Config.RefreshCache,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				Label: plugin.MakeTranslator(i18n.ConfigRefreshCacheDescription),
+			},
+		},
+		{
+			Name:        "cdn_endpoint",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigCDNEndpointTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigCDNEndpointDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.CDNEndpoint,
+		},
 	}
 }
 
@@ -484,6 +470,6 @@ func (c *CDN) ConfigReceiver(config []byte) error {
 	_ = json.Unmarshal(config, cfg)
 	c.Config = cfg
 
-	go c.scanFiles()
+	go c.scanFiles(staticPrefix.Begin(), c.Config)
 	return nil
 }
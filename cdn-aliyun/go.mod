@@ -11,6 +11,7 @@ require (
 
 require (
 	github.com/LinkinStars/go-i18n/v2 v2.2.2 // indirect
+	github.com/andybalholm/brotli v1.2.6 // indirect
 	github.com/asaskevich/govalidator v0.0.0-20230301143203-a9d515a09cc2 // indirect
 	github.com/aymerick/douceur v0.2.0 // indirect
 	github.com/bytedance/sonic v1.12.2 // indirect
@@ -56,3 +57,5 @@ require (
 	xorm.io/builder v0.3.13 // indirect
 	xorm.io/xorm v1.3.2 // indirect
 )
+
+replace github.com/apache/answer-plugins/util => ../util
@@ -37,6 +37,26 @@ const (
 	ConfigVisitUrlPrefixDescription  = "plugin.aliyun_cdn.backend.config.visit_url_prefix.description"
 	ConfigMaxFileSizeTitle           = "plugin.aliyun_cdn.backend.config.max_file_size.title"
 	ConfigMaxFileSizeDescription     = "plugin.aliyun_cdn.backend.config.max_file_size.description"
+	ConfigCompressionTitle           = "plugin.aliyun_cdn.backend.config.compression.title"
+	ConfigCompressionDescription     = "plugin.aliyun_cdn.backend.config.compression.description"
+	ConfigCompressionOptionsNone     = "plugin.aliyun_cdn.backend.config.compression.options.none"
+	ConfigCompressionOptionsGzip     = "plugin.aliyun_cdn.backend.config.compression.options.gzip"
+	ConfigCompressionOptionsVariants = "plugin.aliyun_cdn.backend.config.compression.options.variants"
+	ConfigVersionedTitle             = "plugin.aliyun_cdn.backend.config.versioned.title"
+	ConfigVersionedDescription       = "plugin.aliyun_cdn.backend.config.versioned.description"
+	ConfigKeepReleasesTitle          = "plugin.aliyun_cdn.backend.config.keep_releases.title"
+	ConfigKeepReleasesDescription    = "plugin.aliyun_cdn.backend.config.keep_releases.description"
+	ConfigCleanupTitle               = "plugin.aliyun_cdn.backend.config.cleanup.title"
+	ConfigCleanupDescription         = "plugin.aliyun_cdn.backend.config.cleanup.description"
+	ConfigCleanupOptionsOff          = "plugin.aliyun_cdn.backend.config.cleanup.options.off"
+	ConfigCleanupOptionsDryRun       = "plugin.aliyun_cdn.backend.config.cleanup.options.dry_run"
+	ConfigCleanupOptionsDelete       = "plugin.aliyun_cdn.backend.config.cleanup.options.delete"
+	ConfigUnversionedTitle           = "plugin.aliyun_cdn.backend.config.clean_unversioned.title"
+	ConfigUnversionedDescription     = "plugin.aliyun_cdn.backend.config.clean_unversioned.description"
+	ConfigRefreshCacheTitle          = "plugin.aliyun_cdn.backend.config.refresh_cache.title"
+	ConfigRefreshCacheDescription    = "plugin.aliyun_cdn.backend.config.refresh_cache.description"
+	ConfigCDNEndpointTitle           = "plugin.aliyun_cdn.backend.config.cdn_endpoint.title"
+	ConfigCDNEndpointDescription     = "plugin.aliyun_cdn.backend.config.cdn_endpoint.description"
 
 	ErrMisStorageConfig    = "plugin.aliyun_cdn.backend.err.mis_storage_config"
 	ErrUnsupportedFileType = "plugin.aliyun_cdn.backend.err.unsupported_file_type"
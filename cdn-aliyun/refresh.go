@@ -0,0 +1,152 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package aliyun
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultCDNEndpoint is the endpoint of the aliyun cdn api
+	defaultCDNEndpoint = "cdn.aliyuncs.com"
+	// cdnAPIVersion is the version of the aliyun cdn api
+	cdnAPIVersion = "2018-05-10"
+	// maxRefreshURLs is the number of urls refreshed one by one at most,
+	// more changed objects refresh the directory of the key prefix instead
+	maxRefreshURLs = 1000
+)
+
+var refreshClient = &http.Client{Timeout: 30 * time.Second}
+
+// refreshURLs returns the visit urls of the keys to refresh and their object type, File or Directory
+func refreshURLs(visitURLPrefix, keyPrefix string, keys []string) ([]string, string) {
+	if len(keys) > maxRefreshURLs {
+		return []string{visitURLPrefix + keyPrefix}, "Directory"
+	}
+	urls := make([]string, 0, len(keys))
+	for _, key := range keys {
+		urls = append(urls, visitURLPrefix+key)
+	}
+	return urls, "File"
+}
+
+// refreshObjectCaches calls RefreshObjectCaches of the aliyun cdn api and returns the id of the refresh task.
+// The urls are posted in the form body, in a query they would easily exceed the url length limit.
+func refreshObjectCaches(config *CDNConfig, urls []string, objectType string) (string, error) {
+	endpoint := config.CDNEndpoint
+	if len(endpoint) == 0 {
+		endpoint = defaultCDNEndpoint
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+
+	params := url.Values{}
+	params.Set("Action", "RefreshObjectCaches")
+	params.Set("ObjectPath", strings.Join(urls, "\n"))
+	params.Set("ObjectType", objectType)
+	form, err := signRPC(http.MethodPost, params, config.AccessKeyID, config.AccessKeySecret, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := refreshClient.Post(strings.TrimSuffix(endpoint, "/")+"/",
+		"application/x-www-form-urlencoded", strings.NewReader(form))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	result := &struct {
+		RefreshTaskId string `json:"RefreshTaskId"`
+		RequestId     string `json:"RequestId"`
+		Code          string `json:"Code"`
+		Message       string `json:"Message"`
+	}{}
+	if err = json.Unmarshal(body, result); err != nil {
+		return "", fmt.Errorf("parse response failed, status %s: %w", resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("refresh object caches failed, %s: %s %s", resp.Status, result.Code, result.Message)
+	}
+	return result.RefreshTaskId, nil
+}
+
+// signRPC adds the common parameters of the aliyun rpc api to params and returns them signed for the method,
+// as the query or the form body
+func signRPC(method string, params url.Values, accessKeyID, accessKeySecret string, now time.Time) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	params.Set("Format", "JSON")
+	params.Set("Version", cdnAPIVersion)
+	params.Set("AccessKeyId", accessKeyID)
+	params.Set("SignatureMethod", "HMAC-SHA1")
+	params.Set("SignatureVersion", "1.0")
+	params.Set("SignatureNonce", hex.EncodeToString(nonce))
+	params.Set("Timestamp", now.UTC().Format("2006-01-02T15:04:05Z"))
+	params.Set("Signature", rpcSignature(method, params, accessKeySecret))
+	return canonicalizedQuery(params), nil
+}
+
+// rpcSignature signs the sorted and percent encoded parameters with HMAC-SHA1
+func rpcSignature(method string, params url.Values, accessKeySecret string) string {
+	stringToSign := method + "&" + percentEncode("/") + "&" + percentEncode(canonicalizedQuery(params))
+	mac := hmac.New(sha1.New, []byte(accessKeySecret+"&"))
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func canonicalizedQuery(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, percentEncode(key)+"="+percentEncode(params.Get(key)))
+	}
+	return strings.Join(pairs, "&")
+}
+
+// percentEncode encodes as RFC 3986 requires, which the aliyun rpc signature is computed with
+func percentEncode(s string) string {
+	s = url.QueryEscape(s)
+	s = strings.ReplaceAll(s, "+", "%20")
+	s = strings.ReplaceAll(s, "*", "%2A")
+	return strings.ReplaceAll(s, "%7E", "~")
+}
@@ -0,0 +1,101 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package aliyun
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRPCSignature(t *testing.T) {
+	// the example of the signature document of the aliyun rpc api
+	params := url.Values{}
+	params.Set("AccessKeyId", "testid")
+	params.Set("Action", "DescribeRegions")
+	params.Set("Format", "XML")
+	params.Set("SignatureMethod", "HMAC-SHA1")
+	params.Set("SignatureNonce", "3ee8c1b8-83d3-44af-a94f-4e0ad82fd6cf")
+	params.Set("SignatureVersion", "1.0")
+	params.Set("Timestamp", "2016-02-23T12:46:24Z")
+	params.Set("Version", "2014-05-26")
+	if got := rpcSignature(http.MethodGet, params, "testsecret"); got != "OLeaidS1JvxuMvnyHOwuJ+uX5qY=" {
+		t.Errorf("rpcSignature() = %s", got)
+	}
+}
+
+func TestRefreshObjectCaches(t *testing.T) {
+	// long paths of many files exceed the url length limit, they are posted in the form body
+	urls := make([]string, maxRefreshURLs)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://cdn.example.com/answer/%s/%d.js", strings.Repeat("static/js", 20), i)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || len(r.URL.RawQuery) > 0 {
+			t.Errorf("request = %s %s", r.Method, r.URL)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		query := r.PostForm
+		signature := query.Get("Signature")
+		query.Del("Signature")
+		if signature != rpcSignature(r.Method, query, "secret") {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprint(w, `{"Code":"SignatureDoesNotMatch","Message":"signature mismatch"}`)
+			return
+		}
+		if query.Get("Action") != "RefreshObjectCaches" || query.Get("ObjectType") != "File" ||
+			query.Get("AccessKeyId") != "id" {
+			t.Errorf("query = %v", query)
+		}
+		if paths := strings.Split(query.Get("ObjectPath"), "\n"); strings.Join(paths, ",") != strings.Join(urls, ",") {
+			t.Errorf("ObjectPath has %d urls, want %d", len(paths), len(urls))
+		}
+		_, _ = fmt.Fprint(w, `{"RefreshTaskId":"704222904","RequestId":"D61E4801-EAFF-4A63-AAE1-FBF6CE1CFD1C"}`)
+	}))
+	defer server.Close()
+
+	config := &CDNConfig{AccessKeyID: "id", AccessKeySecret: "secret", CDNEndpoint: server.URL}
+	id, err := refreshObjectCaches(config, urls, "File")
+	if err != nil || id != "704222904" {
+		t.Errorf("refreshObjectCaches() = %s, %v", id, err)
+	}
+
+	config.AccessKeySecret = "wrong"
+	if _, err = refreshObjectCaches(config, urls, "File"); err == nil || !strings.Contains(err.Error(), "SignatureDoesNotMatch") {
+		t.Errorf("refreshObjectCaches() = %v, want the api error", err)
+	}
+}
+
+func TestRefreshURLs(t *testing.T) {
+	urls, objectType := refreshURLs("https://cdn.example.com/", "answer/", []string{"answer/asset-manifest.json"})
+	if objectType != "File" || len(urls) != 1 || urls[0] != "https://cdn.example.com/answer/asset-manifest.json" {
+		t.Errorf("refreshURLs() = %v, %s", urls, objectType)
+	}
+	keys := make([]string, maxRefreshURLs+1)
+	urls, objectType = refreshURLs("https://cdn.example.com/", "answer/", keys)
+	if objectType != "Directory" || len(urls) != 1 || urls[0] != "https://cdn.example.com/answer/" {
+		t.Errorf("refreshURLs() of many keys = %v, %s", urls, objectType)
+	}
+}
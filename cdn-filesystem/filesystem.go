@@ -0,0 +1,232 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package filesystem
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/apache/answer-plugins/cdn-filesystem/i18n"
+	"github.com/apache/answer-plugins/util"
+	"github.com/apache/answer-plugins/util/cdn"
+	"github.com/apache/answer/plugin"
+	"github.com/apache/answer/ui"
+	"github.com/segmentfault/pacman/log"
+)
+
+var (
+	staticPath = os.Getenv("ANSWER_STATIC_PATH")
+	// staticPrefix switches to the CDN once the release is published
+	staticPrefix = &cdn.StaticPrefix{}
+	// publishMu serializes the publishing, so a release is never removed while another sync writes it
+	publishMu sync.Mutex
+)
+
+//go:embed  info.yaml
+var Info embed.FS
+
+const (
+	// defaultKeepReleases is the number of previous releases kept in the directory
+	defaultKeepReleases = 2
+)
+
+type CDN struct {
+	Config *CDNConfig
+}
+
+type CDNConfig struct {
+	Directory      string `json:"directory"`
+	VisitUrlPrefix string `json:"visit_url_prefix"`
+	Compression    string `json:"compression"`
+	KeepReleases   string `json:"keep_releases"`
+}
+
+func init() {
+	plugin.Register(&CDN{
+		Config: &CDNConfig{},
+	})
+}
+
+func (c *CDN) Info() plugin.Info {
+	info := util.Info{}
+	info.GetInfo(Info)
+	return plugin.Info{
+		Name:        plugin.MakeTranslator(i18n.InfoName),
+		SlugName:    info.SlugName,
+		Description: plugin.MakeTranslator(i18n.InfoDescription),
+		Author:      info.Author,
+		Version:     info.Version,
+		Link:        info.Link,
+	}
+}
+
+// GetStaticPrefix get static prefix
+func (c *CDN) GetStaticPrefix() string {
+	return staticPrefix.Get()
+}
+
+// scanFiles publishes the static files as a release in the directory, the static prefix switches to
+// the release when it is published and the sync is still the latest one
+func (c *CDN) scanFiles(generation int64, config *CDNConfig) {
+	if len(config.Directory) == 0 || len(config.VisitUrlPrefix) == 0 {
+		log.Warn("skip: publish static files, the directory or the access url prefix is not set")
+		return
+	}
+	build, err := cdn.BuildFS(ui.Build, staticPath)
+	if err != nil {
+		log.Error("failed: open static files: ", err)
+		return
+	}
+	name, err := releaseName(build, config)
+	if err != nil {
+		log.Error("failed: read static files: ", err)
+		return
+	}
+	publishRelease(generation, config, name, build)
+}
+
+// publishRelease writes the release and switches to it, an earlier sync only writes its release
+// and leaves the switch and the removal of the old releases to the latest one
+func publishRelease(generation int64, config *CDNConfig, name string, build fs.FS) {
+	publishMu.Lock()
+	defer publishMu.Unlock()
+
+	dir := &releaseDir{root: config.Directory}
+	published, err := dir.publish(name, &cdn.Syncer{
+		VisitURLPrefix: config.VisitUrlPrefix + name + "/",
+		FileTypes:      plugin.DefaultCDNFileType,
+		Compression:    config.Compression,
+		Logf:           log.Warnf,
+		Progress: func(uploaded, total int) {
+			log.Infof("write static files: %d/%d", uploaded, total)
+		},
+	}, build)
+	if err != nil {
+		log.Error("failed: publish static files: ", err)
+		return
+	}
+	if !staticPrefix.Latest(generation) {
+		log.Infof("skip: switch to release %s, a later sync has begun", name)
+		return
+	}
+	if err = dir.swapCurrent(name); err != nil {
+		log.Error("failed: switch the current release: ", err)
+		return
+	}
+	staticPrefix.Complete(generation, config.VisitUrlPrefix+name+"/")
+	if published {
+		log.Infof("complete: publish static files, release %s", name)
+	} else {
+		log.Infof("complete: publish static files, release %s is published already", name)
+	}
+
+	removed, err := dir.removeReleases(name, config.keepReleases())
+	if err != nil {
+		log.Error("failed: remove old releases: ", err)
+		return
+	}
+	if len(removed) > 0 {
+		log.Infof("complete: remove old releases %v", removed)
+	}
+}
+
+func (cfg *CDNConfig) keepReleases() int {
+	if len(cfg.KeepReleases) == 0 {
+		return defaultKeepReleases
+	}
+	keep, err := strconv.Atoi(cfg.KeepReleases)
+	if err != nil || keep < 0 {
+		return defaultKeepReleases
+	}
+	return keep
+}
+
+func (c *CDN) ConfigFields() []plugin.ConfigField {
+	return []plugin.ConfigField{
+		{
+			Name:        "directory",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigDirectoryTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigDirectoryDescription),
+			Required:    true,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.Directory,
+		},
+		{
+			Name:        "visit_url_prefix",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigVisitUrlPrefixTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigVisitUrlPrefixDescription),
+			Required:    true,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.VisitUrlPrefix,
+		},
+		{
+			Name:        "compression",
+			Type:        plugin.ConfigTypeSelect,
+			Title:       plugin.MakeTranslator(i18n.ConfigCompressionTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigCompressionDescription),
+			Required:    false,
+			Options: []plugin.ConfigFieldOption{
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigCompressionOptionsNone),
+					Value: cdn.CompressionNone,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigCompressionOptionsVariants),
+					Value: cdn.CompressionVariants,
+				},
+			},
+			Value: c.Config.Compression,
+		},
+		{
+			Name:        "keep_releases",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigKeepReleasesTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigKeepReleasesDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.KeepReleases,
+		},
+	}
+}
+
+func (c *CDN) ConfigReceiver(config []byte) error {
+	cfg := &CDNConfig{}
+	_ = json.Unmarshal(config, cfg)
+	// gzip in place is not an option, the web servers would not know the files are compressed
+	if cfg.Compression != cdn.CompressionVariants {
+		cfg.Compression = cdn.CompressionNone
+	}
+	c.Config = cfg
+
+	go c.scanFiles(staticPrefix.Begin(), c.Config)
+	return nil
+}
@@ -0,0 +1,36 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package i18n
+
+const (
+	InfoName        = "plugin.filesystem_cdn.backend.info.name"
+	InfoDescription = "plugin.filesystem_cdn.backend.info.description"
+
+	ConfigDirectoryTitle             = "plugin.filesystem_cdn.backend.config.directory.title"
+	ConfigDirectoryDescription       = "plugin.filesystem_cdn.backend.config.directory.description"
+	ConfigVisitUrlPrefixTitle        = "plugin.filesystem_cdn.backend.config.visit_url_prefix.title"
+	ConfigVisitUrlPrefixDescription  = "plugin.filesystem_cdn.backend.config.visit_url_prefix.description"
+	ConfigCompressionTitle           = "plugin.filesystem_cdn.backend.config.compression.title"
+	ConfigCompressionDescription     = "plugin.filesystem_cdn.backend.config.compression.description"
+	ConfigCompressionOptionsNone     = "plugin.filesystem_cdn.backend.config.compression.options.none"
+	ConfigCompressionOptionsVariants = "plugin.filesystem_cdn.backend.config.compression.options.variants"
+	ConfigKeepReleasesTitle          = "plugin.filesystem_cdn.backend.config.keep_releases.title"
+	ConfigKeepReleasesDescription    = "plugin.filesystem_cdn.backend.config.keep_releases.description"
+)
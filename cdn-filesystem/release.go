@@ -0,0 +1,184 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package filesystem
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/apache/answer-plugins/util/cdn"
+)
+
+// currentLink is the name of the symlink pointing to the current release
+const currentLink = "current"
+
+// releaseNameLength is the number of hex characters of the sha256 naming a release
+const releaseNameLength = 12
+
+var releasePattern = regexp.MustCompile(`^[0-9a-f]{12}$`)
+
+// releaseName names the release by the files of the build, the access url prefix and the compression,
+// as the rewritten files depend on them as well
+func releaseName(build fs.FS, config *CDNConfig) (string, error) {
+	release, err := cdn.Release(build)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256([]byte(release + "\n" + config.VisitUrlPrefix + "\n" + config.Compression))
+	return hex.EncodeToString(h[:])[:releaseNameLength], nil
+}
+
+// releaseDir is the directory of the releases, every release is a subdirectory named by releaseName
+type releaseDir struct {
+	root string
+}
+
+// publish writes the build to a temporary directory and renames it to the release, so the web servers
+// never see a release partly written. A release published already is reused.
+func (d *releaseDir) publish(name string, syncer *cdn.Syncer, build fs.FS) (published bool, err error) {
+	target := filepath.Join(d.root, name)
+	if _, err = os.Stat(target); err == nil {
+		// touched to count as the latest release when removing the old ones
+		now := time.Now()
+		return false, os.Chtimes(target, now, now)
+	}
+	if err = os.MkdirAll(d.root, 0o755); err != nil {
+		return false, err
+	}
+	tmp, err := os.MkdirTemp(d.root, "."+name+"-")
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.RemoveAll(tmp)
+		}
+	}()
+
+	syncer.Provider = &dirProvider{root: tmp}
+	if _, err = syncer.Sync(build); err != nil {
+		return false, err
+	}
+	// MkdirTemp creates the directory readable by the owner only
+	if err = os.Chmod(tmp, 0o755); err != nil {
+		return false, err
+	}
+	if err = os.Rename(tmp, target); err != nil {
+		if _, serr := os.Stat(target); serr == nil {
+			// published by another sync in the meantime
+			err = nil
+			_ = os.RemoveAll(tmp)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// swapCurrent points the current symlink to the release, a new symlink is renamed over the old one,
+// so it is replaced atomically
+func (d *releaseDir) swapCurrent(name string) error {
+	link := filepath.Join(d.root, currentLink)
+	if target, err := os.Readlink(link); err == nil && target == name {
+		return nil
+	}
+	tmp := filepath.Join(d.root, "."+currentLink+"-"+name)
+	_ = os.Remove(tmp)
+	if err := os.Symlink(name, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, link); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// removeReleases removes the releases but the current one and the keep latest others,
+// the other files in the directory are left alone
+func (d *releaseDir) removeReleases(current string, keep int) ([]string, error) {
+	entries, err := os.ReadDir(d.root)
+	if err != nil {
+		return nil, err
+	}
+	type release struct {
+		name    string
+		modTime time.Time
+	}
+	var releases []release
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == current || !releasePattern.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, release{name: entry.Name(), modTime: info.ModTime()})
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		return releases[i].modTime.After(releases[j].modTime)
+	})
+
+	var removed []string
+	for _, r := range releases[min(keep, len(releases)):] {
+		if err = os.RemoveAll(filepath.Join(d.root, r.name)); err != nil {
+			return removed, err
+		}
+		removed = append(removed, r.name)
+	}
+	return removed, nil
+}
+
+// dirProvider writes the objects as files under the root
+type dirProvider struct {
+	root string
+}
+
+func (p *dirProvider) PutObject(object *cdn.Object) error {
+	name := filepath.Join(p.root, filepath.FromSlash(object.Key))
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(file, object.Body); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (p *dirProvider) GetObject(key string) ([]byte, error) {
+	content, err := os.ReadFile(filepath.Join(p.root, filepath.FromSlash(key)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, cdn.ErrObjectNotExist
+	}
+	return content, err
+}
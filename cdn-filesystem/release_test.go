@@ -0,0 +1,189 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/apache/answer-plugins/util/cdn"
+)
+
+func testBuild() fstest.MapFS {
+	return fstest.MapFS{
+		"index.html":          {Data: []byte("<html></html>")},
+		"asset-manifest.json": {Data: []byte(`{"main.js": "/static/js/main.1a2b3c4d.js"}`)},
+		"static/js/main.1a2b3c4d.js": {Data: []byte(
+			`a="static/js/2.1a2b3c4d.chunk.js",p="/",` + strings.Repeat("console.log(1);", 100))},
+		"static/media/bg.png": {Data: []byte("png")},
+	}
+}
+
+func TestReleaseDir_Publish(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "cdn")
+	config := &CDNConfig{VisitUrlPrefix: "https://static.example.com/", Compression: cdn.CompressionVariants}
+	build := testBuild()
+	name, err := releaseName(build, config)
+	if err != nil || !releasePattern.MatchString(name) {
+		t.Fatalf("releaseName() = %s, %v", name, err)
+	}
+	newSyncer := func() *cdn.Syncer {
+		return &cdn.Syncer{
+			VisitURLPrefix: config.VisitUrlPrefix + name + "/",
+			FileTypes:      map[string]bool{".js": true, ".json": true, ".png": true},
+			Compression:    config.Compression,
+		}
+	}
+
+	d := &releaseDir{root: root}
+	published, err := d.publish(name, newSyncer(), build)
+	if err != nil || !published {
+		t.Fatalf("publish() = %v, %v", published, err)
+	}
+	info, err := os.Stat(filepath.Join(root, name))
+	if err != nil || info.Mode().Perm() != 0o755 {
+		t.Fatalf("release directory = %v, %v", info, err)
+	}
+	manifest, _ := os.ReadFile(filepath.Join(root, name, "asset-manifest.json"))
+	if string(manifest) != `{"main.js": "https://static.example.com/`+name+`/static/js/main.1a2b3c4d.js"}` {
+		t.Errorf("asset-manifest.json = %s", manifest)
+	}
+	for _, file := range []string{"static/js/main.1a2b3c4d.js", "static/js/main.1a2b3c4d.js.gz",
+		"static/js/main.1a2b3c4d.js.br", "static/media/bg.png"} {
+		if _, err = os.Stat(filepath.Join(root, name, filepath.FromSlash(file))); err != nil {
+			t.Errorf("%s is not written: %v", file, err)
+		}
+	}
+
+	published, err = d.publish(name, newSyncer(), build)
+	if err != nil || published {
+		t.Errorf("publish() of a published release = %v, %v", published, err)
+	}
+	entries, _ := os.ReadDir(root)
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, the temporary directory is left", len(entries))
+	}
+
+	other, _ := releaseName(build, &CDNConfig{VisitUrlPrefix: "https://cdn.example.com/"})
+	if other == name {
+		t.Error("releaseName() does not depend on the access url prefix")
+	}
+}
+
+func TestReleaseDir_SwapCurrent(t *testing.T) {
+	d := &releaseDir{root: t.TempDir()}
+	for _, name := range []string{"111111111111", "222222222222", "222222222222"} {
+		if err := d.swapCurrent(name); err != nil {
+			t.Fatal(err)
+		}
+		if target, err := os.Readlink(filepath.Join(d.root, currentLink)); err != nil || target != name {
+			t.Errorf("current = %s, %v, want %s", target, err, name)
+		}
+	}
+	entries, _ := os.ReadDir(d.root)
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, the temporary link is left", len(entries))
+	}
+}
+
+func TestReleaseDir_RemoveReleases(t *testing.T) {
+	d := &releaseDir{root: t.TempDir()}
+	names := []string{"111111111111", "222222222222", "333333333333", "444444444444"}
+	now := time.Now()
+	for i, name := range names {
+		dir := filepath.Join(d.root, name, "static")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		modTime := now.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(filepath.Join(d.root, name), modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(d.root, "uploads"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.swapCurrent("111111111111"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the current release is the oldest one, it is kept with the latest other one
+	removed, err := d.removeReleases("111111111111", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(removed)
+	if strings.Join(removed, ",") != "222222222222,333333333333" {
+		t.Errorf("removeReleases() = %v", removed)
+	}
+	entries, _ := os.ReadDir(d.root)
+	var left []string
+	for _, entry := range entries {
+		left = append(left, entry.Name())
+	}
+	if strings.Join(left, ",") != "111111111111,444444444444,current,uploads" {
+		t.Errorf("directory = %v", left)
+	}
+}
+
+func TestPublishRelease(t *testing.T) {
+	root := t.TempDir()
+	config := &CDNConfig{Directory: root, VisitUrlPrefix: "https://static.example.com/", KeepReleases: "0"}
+	build := testBuild()
+	name, err := releaseName(build, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := filepath.Join(root, "111111111111")
+	if err = os.Mkdir(old, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// an earlier sync writes its release, the later one switches to its own
+	earlier := staticPrefix.Begin()
+	staticPrefix.Begin()
+	publishRelease(earlier, config, name, build)
+	if _, err = os.Stat(filepath.Join(root, name)); err != nil {
+		t.Errorf("release is not written: %v", err)
+	}
+	if _, err = os.Lstat(filepath.Join(root, currentLink)); !os.IsNotExist(err) {
+		t.Errorf("an earlier sync switches the current release: %v", err)
+	}
+	if _, err = os.Stat(old); err != nil {
+		t.Errorf("an earlier sync removes old releases: %v", err)
+	}
+
+	latest := staticPrefix.Begin()
+	publishRelease(latest, config, name, build)
+	if target, err := os.Readlink(filepath.Join(root, currentLink)); err != nil || target != name {
+		t.Errorf("current = %s, %v, want %s", target, err, name)
+	}
+	if _, err = os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("old release is not removed: %v", err)
+	}
+	if got := staticPrefix.Get(); got != config.VisitUrlPrefix+name+"/" {
+		t.Errorf("static prefix = %s", got)
+	}
+}
@@ -43,6 +43,27 @@ const (
 	ConfigRegionDescription          = "plugin.s3_cdn.backend.config.region.description"
 	ConfigDisableSSLTitle            = "plugin.s3_cdn.backend.config.disable_ssl.title"
 	ConfigDisableSSLDescription      = "plugin.s3_cdn.backend.config.disable_ssl.description"
+	ConfigCompressionTitle           = "plugin.s3_cdn.backend.config.compression.title"
+	ConfigCompressionDescription     = "plugin.s3_cdn.backend.config.compression.description"
+	ConfigCompressionOptionsNone     = "plugin.s3_cdn.backend.config.compression.options.none"
+	ConfigCompressionOptionsGzip     = "plugin.s3_cdn.backend.config.compression.options.gzip"
+	ConfigCompressionOptionsVariants = "plugin.s3_cdn.backend.config.compression.options.variants"
+	ConfigVersionedTitle             = "plugin.s3_cdn.backend.config.versioned.title"
+	ConfigVersionedDescription       = "plugin.s3_cdn.backend.config.versioned.description"
+	ConfigKeepReleasesTitle          = "plugin.s3_cdn.backend.config.keep_releases.title"
+	ConfigKeepReleasesDescription    = "plugin.s3_cdn.backend.config.keep_releases.description"
+	ConfigCleanupTitle               = "plugin.s3_cdn.backend.config.cleanup.title"
+	ConfigCleanupDescription         = "plugin.s3_cdn.backend.config.cleanup.description"
+	ConfigCleanupOptionsOff          = "plugin.s3_cdn.backend.config.cleanup.options.off"
+	ConfigCleanupOptionsDryRun       = "plugin.s3_cdn.backend.config.cleanup.options.dry_run"
+	ConfigCleanupOptionsDelete       = "plugin.s3_cdn.backend.config.cleanup.options.delete"
+	ConfigUnversionedTitle           = "plugin.s3_cdn.backend.config.clean_unversioned.title"
+	ConfigUnversionedDescription     = "plugin.s3_cdn.backend.config.clean_unversioned.description"
+
+	ConfigCloudFrontDistributionIDTitle       = "plugin.s3_cdn.backend.config.cloudfront_distribution_id.title"
+	ConfigCloudFrontDistributionIDDescription = "plugin.s3_cdn.backend.config.cloudfront_distribution_id.description"
+	ConfigCloudFrontEndpointTitle             = "plugin.s3_cdn.backend.config.cloudfront_endpoint.title"
+	ConfigCloudFrontEndpointDescription       = "plugin.s3_cdn.backend.config.cloudfront_endpoint.description"
 
 	ErrFileNotFound        = "plugin.s3_cdn.backend.err.file_not_found"
 	ErrUnsupportedFileType = "plugin.s3_cdn.backend.err.unsupported_file_type"
@@ -0,0 +1,86 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package s3
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+)
+
+// maxInvalidationPaths is the number of paths invalidated one by one at most,
+// more changed objects invalidate everything under the key prefix instead
+const maxInvalidationPaths = 1000
+
+// invalidationPaths returns the paths of the objects on the distribution, the path of the visit url of each key
+func invalidationPaths(visitURLPrefix, keyPrefix string, keys []string) []string {
+	if len(keys) > maxInvalidationPaths {
+		return []string{visitPath(visitURLPrefix+keyPrefix) + "*"}
+	}
+	paths := make([]string, 0, len(keys))
+	for _, key := range keys {
+		paths = append(paths, visitPath(visitURLPrefix+key))
+	}
+	return paths
+}
+
+func visitPath(visitURL string) string {
+	u, err := url.Parse(visitURL)
+	if err != nil || len(u.Path) == 0 {
+		return "/"
+	}
+	return u.Path
+}
+
+// invalidate creates a CloudFront invalidation of the paths and returns its id
+func invalidate(config *CDNConfig, paths []string) (string, error) {
+	cfg := &aws.Config{
+		Credentials: credentials.NewStaticCredentials(config.AccessKeyID, config.AccessKeySecret, config.AccessToken),
+		// CloudFront is a global service signed in us-east-1
+		Region: aws.String("us-east-1"),
+	}
+	if len(config.CloudFrontEndpoint) > 0 {
+		cfg.Endpoint = aws.String(config.CloudFrontEndpoint)
+	}
+	newSession, err := session.NewSession(cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to create session, %s", err.Error())
+	}
+	output, err := cloudfront.New(newSession).CreateInvalidation(&cloudfront.CreateInvalidationInput{
+		DistributionId: aws.String(config.CloudFrontDistributionID),
+		InvalidationBatch: &cloudfront.InvalidationBatch{
+			CallerReference: aws.String(strconv.FormatInt(time.Now().UnixNano(), 10)),
+			Paths: &cloudfront.Paths{
+				Quantity: aws.Int64(int64(len(paths))),
+				Items:    aws.StringSlice(paths),
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create invalidation, %s", err.Error())
+	}
+	return aws.StringValue(output.Invalidation.Id), nil
+}
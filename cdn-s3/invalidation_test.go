@@ -0,0 +1,71 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package s3
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInvalidationPaths(t *testing.T) {
+	paths := invalidationPaths("https://d1.cloudfront.net/", "answer/",
+		[]string{"answer/asset-manifest.json", "answer/static/js/main.js.gz"})
+	if strings.Join(paths, ",") != "/answer/asset-manifest.json,/answer/static/js/main.js.gz" {
+		t.Errorf("invalidationPaths() = %v", paths)
+	}
+	paths = invalidationPaths("https://d1.cloudfront.net/", "answer/", make([]string, maxInvalidationPaths+1))
+	if strings.Join(paths, ",") != "/answer/*" {
+		t.Errorf("invalidationPaths() of many keys = %v", paths)
+	}
+}
+
+func TestInvalidate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/distribution/E2QWRUHAPOMQZL/invalidation") {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		if !strings.Contains(string(body), "<Path>/asset-manifest.json</Path>") {
+			t.Errorf("body = %s", body)
+		}
+		if !strings.Contains(r.Header.Get("Authorization"), "Credential=id/") {
+			t.Errorf("Authorization = %s", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?>
+<Invalidation xmlns="http://cloudfront.amazonaws.com/doc/2020-05-31/"><Id>I2J0I21PCUYOIK</Id><Status>InProgress</Status></Invalidation>`)
+	}))
+	defer server.Close()
+
+	config := &CDNConfig{
+		AccessKeyID:              "id",
+		AccessKeySecret:          "secret",
+		CloudFrontDistributionID: "E2QWRUHAPOMQZL",
+		CloudFrontEndpoint:       server.URL,
+	}
+	id, err := invalidate(config, []string{"/asset-manifest.json"})
+	if err != nil || id != "I2J0I21PCUYOIK" {
+		t.Errorf("invalidate() = %s, %v", id, err)
+	}
+}
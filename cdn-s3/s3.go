@@ -20,22 +20,14 @@
 package s3
 
 import (
-	"crypto/rand"
 	"embed"
-	"encoding/hex"
 	"encoding/json"
-	"fmt"
-	"io"
-	"io/fs"
-	"net/http"
 	"os"
-	"path/filepath"
 	"strconv"
-	"strings"
-	"time"
 
 	"github.com/apache/answer-plugins/cdn-s3/i18n"
 	"github.com/apache/answer-plugins/util"
+	"github.com/apache/answer-plugins/util/cdn"
 	"github.com/apache/answer/plugin"
 	"github.com/apache/answer/ui"
 	"github.com/segmentfault/pacman/log"
@@ -43,7 +35,8 @@ import (
 
 var (
 	staticPath = os.Getenv("ANSWER_STATIC_PATH")
-	enable     = false
+	// staticPrefix switches to the CDN once every static file is uploaded
+	staticPrefix = &cdn.StaticPrefix{}
 )
 
 //go:embed  info.yaml
@@ -52,6 +45,8 @@ var Info embed.FS
 const (
 	// 10MB
 	defaultMaxFileSize int64 = 10 * 1024 * 1024
+	// defaultKeepReleases is the number of previous releases kept by the cleanup
+	defaultKeepReleases = 2
 )
 
 type CDN struct {
@@ -60,16 +55,24 @@ type CDN struct {
 }
 
 type CDNConfig struct {
-	Endpoint        string `json:"endpoint"`
-	BucketName      string `json:"bucket_name"`
-	ObjectKeyPrefix string `json:"object_key_prefix"`
-	AccessKeyID     string `json:"access_key_id"`
-	AccessKeySecret string `json:"access_key_secret"`
-	AccessToken     string `json:"access_token"`
-	VisitUrlPrefix  string `json:"visit_url_prefix"`
-	MaxFileSize     string `json:"max_file_size"`
-	Region          string `json:"region"`
-	DisableSSL      bool   `json:"disable_ssl"`
+	Endpoint         string `json:"endpoint"`
+	BucketName       string `json:"bucket_name"`
+	ObjectKeyPrefix  string `json:"object_key_prefix"`
+	AccessKeyID      string `json:"access_key_id"`
+	AccessKeySecret  string `json:"access_key_secret"`
+	AccessToken      string `json:"access_token"`
+	VisitUrlPrefix   string `json:"visit_url_prefix"`
+	MaxFileSize      string `json:"max_file_size"`
+	Compression      string `json:"compression"`
+	Versioned        bool   `json:"versioned"`
+	KeepReleases     string `json:"keep_releases"`
+	Cleanup          string `json:"cleanup"`
+	CleanUnversioned bool   `json:"clean_unversioned"`
+	Region           string `json:"region"`
+	DisableSSL       bool   `json:"disable_ssl"`
+
+	CloudFrontDistributionID string `json:"cloudfront_distribution_id"`
+	CloudFrontEndpoint       string `json:"cloudfront_endpoint"`
 }
 
 func init() {
@@ -93,287 +96,110 @@ func (c *CDN) Info() plugin.Info {
 
 // GetStaticPrefix get static prefix
 func (c *CDN) GetStaticPrefix() string {
-	if !enable {
-		return ""
-	}
-	return c.Config.VisitUrlPrefix + c.Config.ObjectKeyPrefix
+	return staticPrefix.Get()
 }
 
-// scanFiles scan all the static files in the build directory
-func (c *CDN) scanFiles() {
-	if staticPath == "" {
-		err := c.scanEmbedFiles("build")
-		if err != nil {
-			enable = false
-			log.Error("failed: scan embed files: ", err)
-			return
-		}
-		log.Info("complete: scan embed files")
-		enable = true
-		return
-	}
-
-	err := c.scanStaticPathFiles(staticPath)
+// scanFiles uploads all the static files in the build directory, the static prefix switches to
+// the CDN when all of them are uploaded and the sync is still the latest one
+func (c *CDN) scanFiles(generation int64, config *CDNConfig, client *Client) {
+	build, err := cdn.BuildFS(ui.Build, staticPath)
 	if err != nil {
-		enable = false
-		log.Error("fialed: scan static path files: ", err)
+		log.Error("failed: open static files: ", err)
 		return
 	}
-	enable = true
-	log.Info("complete: scan static path files")
-}
-
-// scanStaticPathFiles scan static path files
-func (c *CDN) scanStaticPathFiles(fileName string) (err error) {
-	if len(fileName) == 0 {
-		return
+	keyPrefix := config.ObjectKeyPrefix
+	release := ""
+	if config.Versioned {
+		if release, err = cdn.Release(build); err != nil {
+			log.Error("failed: read static files: ", err)
+			return
+		}
+		keyPrefix += release + "/"
 	}
-
-	// scan static path files
-	entry, err := os.ReadDir(fileName)
+	syncer := &cdn.Syncer{
+		Provider:       client,
+		VisitURLPrefix: config.VisitUrlPrefix,
+		KeyPrefix:      keyPrefix,
+		MaxFileSize:    config.maxFileSizeLimit(),
+		FileTypes:      plugin.DefaultCDNFileType,
+		Compression:    config.Compression,
+		CheckAvailable: true,
+		Logf:           log.Warnf,
+		Progress: func(uploaded, total int) {
+			log.Infof("upload static files: %d/%d", uploaded, total)
+		},
+	}
+	result, err := syncer.Sync(build)
 	if err != nil {
-		log.Error("read static dir failed: %v", err)
+		log.Error("failed: upload static files: ", err)
 		return
 	}
-	for _, info := range entry {
-		if info.IsDir() {
-			err = c.scanStaticPathFiles(filepath.Join(fileName, info.Name()))
-			if err != nil {
-				return
-			}
-		}
-
-		var file *os.File
-		filePath := filepath.Join(fileName, info.Name())
-		fi, _ := info.Info()
-		size := fi.Size()
-		file, err = os.Open(filePath)
-		if err != nil {
-			log.Error("open file failed: %v", err)
-			return
-		}
+	staticPrefix.Complete(generation, config.VisitUrlPrefix+keyPrefix)
+	log.Infof("complete: upload static files, %d uploaded, %d unchanged, %d skipped",
+		result.Uploaded, result.Unchanged, result.Skipped)
 
-		suffix := staticPath[:1]
-		if suffix != "/" {
-			suffix = ""
-		}
-		filePath = strings.TrimPrefix(filePath, staticPath+suffix)
-
-		// rebuild custom io.Reader
-		ns := strings.Split(info.Name(), ".")
-		if info.Name() == "asset-manifest.json" {
-			err = c.Upload(filePath, c.rebuildReader(file, map[string]string{
-				"\"/static": "",
-			}), size)
-			if err != nil {
-				return
-			}
-			continue
-		}
-		if ns[0] == "main" {
-			ext := strings.ToLower(filepath.Ext(filePath))
-			if ext == ".js" || ext == ".map" {
-				err = c.Upload(filePath, c.rebuildReader(file, map[string]string{
-					"\"static": "",
-					"=\"/\",":  "=\"\",",
-				}), size)
-				if err != nil {
-					return
-				}
-				continue
-			}
-
-			if ext == ".css" {
-				err = c.Upload(filePath, c.rebuildReader(file, map[string]string{
-					"url(/static": "url(../../static",
-				}), size)
-				if err != nil {
-					return
-				}
-				continue
-			}
-		}
-
-		err = c.Upload(filePath, file, size)
-		if err != nil {
-			return
-		}
+	if len(config.CloudFrontDistributionID) > 0 && len(result.Changed) > 0 {
+		invalidateChanged(config, keyPrefix, result.Changed)
+	}
+	if config.Versioned {
+		cleanReleases(client, config, release)
 	}
-	return
 }
 
-func (c *CDN) scanEmbedFiles(fileName string) (err error) {
-	entry, err := ui.Build.ReadDir(fileName)
+// invalidateChanged invalidates the changed objects on the CloudFront distribution
+func invalidateChanged(config *CDNConfig, keyPrefix string, changed []string) {
+	paths := invalidationPaths(config.VisitUrlPrefix, keyPrefix, changed)
+	id, err := invalidate(config, paths)
 	if err != nil {
-		log.Error("read static dir failed: %v", err)
+		log.Error("failed: invalidate changed static files: ", err)
 		return
 	}
-	for _, info := range entry {
-		if info.IsDir() {
-			err = c.scanEmbedFiles(filepath.Join(fileName, info.Name()))
-			if err != nil {
-				return
-			}
-			continue
-		}
-
-		var file fs.File
-		filePath := filepath.Join(fileName, info.Name())
-		fi, _ := info.Info()
-		size := fi.Size()
-		file, err = ui.Build.Open(filePath)
-		defer file.Close()
-		if err != nil {
-			log.Error("open file failed: %v", err)
-			return
-		}
-
-		filePath = strings.TrimPrefix(filePath, "build/")
-
-		// rebuild custom io.Reader
-		ns := strings.Split(info.Name(), ".")
-		if info.Name() == "asset-manifest.json" {
-			err = c.Upload(filePath, c.rebuildReader(file, map[string]string{
-				"\"/static": "",
-			}), size)
-			if err != nil {
-				return
-			}
-			continue
-		}
-
-		if ns[0] == "main" {
-			ext := strings.ToLower(filepath.Ext(filePath))
-			if ext == ".js" || ext == ".map" {
-				err = c.Upload(filePath, c.rebuildReader(file, map[string]string{
-					"\"static": "",
-					"=\"/\",":  "=\"\",",
-				}), size)
-				if err != nil {
-					return
-				}
-				continue
-			}
-
-			if ext == ".css" {
-				err = c.Upload(filePath, c.rebuildReader(file, map[string]string{
-					"url(/static": "url(../../static",
-				}), size)
-				if err != nil {
-					return
-				}
-				continue
-			}
-		}
-
-		err = c.Upload(filePath, c.rebuildReader(file, nil), size)
-		if err != nil {
-			return
-		}
-	}
-	return
+	log.Infof("complete: invalidate changed static files, invalidation %s of %d paths", id, len(paths))
 }
 
-func (c *CDN) rebuildReader(file io.Reader, replaceMap map[string]string) io.ReadSeeker {
-	var (
-		bufr = make([]byte, 0)
-		res  string
-	)
-
-	for {
-		buf := make([]byte, 1024)
-		n, err := file.Read(buf)
-		if err != nil {
-			break
-		}
-		bufr = append(bufr, buf[:n]...)
-	}
-
-	res = string(bufr)
-
-	if replaceMap != nil {
-		for oldStr, newStr := range replaceMap {
-			if oldStr != "" {
-				if newStr == "" {
-					prefix := c.Config.VisitUrlPrefix + c.Config.ObjectKeyPrefix
-					if prefix[len(prefix)-1:] == "/" {
-						prefix = strings.TrimSuffix(prefix, "/")
-					}
-					newStr = "\"" + prefix + "/static"
-				}
-				res = strings.ReplaceAll(res, oldStr, newStr)
-			}
-		}
+// cleanReleases records the release and removes the releases before the kept ones
+func cleanReleases(provider cdn.CleanupProvider, config *CDNConfig, release string) {
+	cleaner := &cdn.Cleaner{
+		Provider:         provider,
+		KeyPrefix:        config.ObjectKeyPrefix,
+		Keep:             config.keepReleases(),
+		Mode:             config.Cleanup,
+		Logf:             log.Infof,
+		CleanUnversioned: config.CleanUnversioned,
 	}
-
-	return strings.NewReader(res)
-}
-func (c *CDN) Upload(filePath string, file io.ReadSeeker, size int64) (err error) {
-
-	if !c.CheckFileType(filePath) {
-		log.Error(plugin.MakeTranslator(i18n.ErrUnsupportedFileType), filePath)
+	result, err := cleaner.Clean(release)
+	if err != nil {
+		log.Error("failed: clean up releases: ", err)
 		return
 	}
-
-	if size > c.maxFileSizeLimit() {
-		log.Error(plugin.MakeTranslator(i18n.ErrOverFileSizeLimit))
+	if len(result.Removed) == 0 && result.Unversioned == 0 {
 		return
 	}
-
-	objectKey := c.createObjectKey(filePath)
-
-	err = c.Client.PutObject(objectKey, strings.ToLower(filepath.Ext(filePath)), file)
-	if err != nil {
-		log.Error(plugin.MakeTranslator(i18n.ErrUploadFileFailed), err)
+	if config.Cleanup == cdn.CleanupDryRun {
+		log.Infof("dry run: clean up releases %v and %d unversioned objects, %d objects would be deleted",
+			result.Removed, result.Unversioned, result.Objects)
 		return
 	}
-	return c.checkCDNAvailable(objectKey)
+	log.Infof("complete: clean up releases %v and %d unversioned objects, %d objects deleted",
+		result.Removed, result.Unversioned, result.Objects)
 }
 
-func (c *CDN) checkCDNAvailable(objectKey string) error {
-	url := c.Config.VisitUrlPrefix + objectKey
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return err
+func (cfg *CDNConfig) keepReleases() int {
+	if len(cfg.KeepReleases) == 0 {
+		return defaultKeepReleases
 	}
-
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer response.Body.Close()
-
-	if response.StatusCode != http.StatusOK {
-		log.Error("check error:", url)
-		return fmt.Errorf("failed to get object, %s", response.Status)
+	keep, err := strconv.Atoi(cfg.KeepReleases)
+	if err != nil || keep < 0 {
+		return defaultKeepReleases
 	}
-	return nil
-}
-
-func (c *CDN) createObjectKey(filePath string) string {
-	return c.Config.ObjectKeyPrefix + filePath
+	return keep
 }
 
-func (c *CDN) randomObjectKey() string {
-	bytes := make([]byte, 4)
-	_, _ = rand.Read(bytes)
-	return fmt.Sprintf("%d", time.Now().UnixNano()) + hex.EncodeToString(bytes)
-}
-
-func (c *CDN) CheckFileType(filePath string) bool {
-	ext := strings.ToLower(filepath.Ext(filePath))
-	if _, ok := plugin.DefaultCDNFileType[ext]; ok {
-		return true
-	}
-	return false
-}
-
-func (c *CDN) maxFileSizeLimit() int64 {
-	if len(c.Config.MaxFileSize) == 0 {
+func (cfg *CDNConfig) maxFileSizeLimit() int64 {
+	if len(cfg.MaxFileSize) == 0 {
 		return defaultMaxFileSize
 	}
-	limit, _ := strconv.Atoi(c.Config.MaxFileSize)
+	limit, _ := strconv.Atoi(cfg.MaxFileSize)
 	if limit <= 0 {
 		return defaultMaxFileSize
 	}
@@ -490,6 +316,101 @@ func (c *CDN) ConfigFields() []plugin.ConfigField {
 				Label: plugin.MakeTranslator(i18n.ConfigDisableSSLDescription),
 			},
 		},
+		{
+			Name:        "compression",
+			Type:        plugin.ConfigTypeSelect,
+			Title:       plugin.MakeTranslator(i18n.ConfigCompressionTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigCompressionDescription),
+			Required:    false,
+			Options: []plugin.ConfigFieldOption{
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigCompressionOptionsNone),
+					Value: cdn.CompressionNone,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigCompressionOptionsGzip),
+					Value: cdn.CompressionGzip,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigCompressionOptionsVariants),
+					Value: cdn.CompressionVariants,
+				},
+			},
+			Value: c.Config.Compression,
+		},
+		{
+			Name:  "versioned",
+			Type:  plugin.ConfigTypeSwitch,
+			Title: plugin.MakeTranslator(i18n.ConfigVersionedTitle),
+			Value: c.Config.Versioned,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				Label: plugin.MakeTranslator(i18n.ConfigVersionedDescription),
+			},
+		},
+		{
+			Name:        "keep_releases",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigKeepReleasesTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigKeepReleasesDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.KeepReleases,
+		},
+		{
+			Name:        "cleanup",
+			Type:        plugin.ConfigTypeSelect,
+			Title:       plugin.MakeTranslator(i18n.ConfigCleanupTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigCleanupDescription),
+			Required:    false,
+			Options: []plugin.ConfigFieldOption{
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigCleanupOptionsOff),
+					Value: cdn.CleanupOff,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigCleanupOptionsDryRun),
+					Value: cdn.CleanupDryRun,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigCleanupOptionsDelete),
+					Value: cdn.CleanupDelete,
+				},
+			},
+			Value: c.Config.Cleanup,
+		},
+		{
+			Name:  "clean_unversioned",
+			Type:  plugin.ConfigTypeSwitch,
+			Title: plugin.MakeTranslator(i18n.ConfigUnversionedTitle),
+			Value: c.Config.CleanUnversioned,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				Label: plugin.MakeTranslator(i18n.ConfigUnversionedDescription),
+			},
+		},
+		{
+			Name:        "cloudfront_distribution_id",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigCloudFrontDistributionIDTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigCloudFrontDistributionIDDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.CloudFrontDistributionID,
+		},
+		{
+			Name:        "cloudfront_endpoint",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigCloudFrontEndpointTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigCloudFrontEndpointDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.CloudFrontEndpoint,
+		},
 	}
 }
 
@@ -506,6 +427,6 @@ func (c *CDN) ConfigReceiver(config []byte) error {
 		c.Config.BucketName,
 		c.Config.DisableSSL,
 	)
-	go c.scanFiles()
+	go c.scanFiles(staticPrefix.Begin(), c.Config, c.Client)
 	return nil
 }
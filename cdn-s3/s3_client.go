@@ -20,16 +20,22 @@
 package s3
 
 import (
+	"errors"
 	"fmt"
 	"io"
-	"strings"
+	"slices"
 
+	"github.com/apache/answer-plugins/util/cdn"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 )
 
+// deleteObjectsLimit is the number of objects deleted by a request at most
+const deleteObjectsLimit = 1000
+
 type Client struct {
 	s3Config *aws.Config
 	bucket   string
@@ -49,39 +55,101 @@ func NewS3Client(id, secret, token, endpoint, region, bucket string, disableSSL
 	return s3Client
 }
 
-func (s *Client) PutObject(key, ext string, file io.ReadSeeker) (err error) {
+func (s *Client) PutObject(object *cdn.Object) (err error) {
 	newSession, err := session.NewSession(s.s3Config)
 	if err != nil {
 		return fmt.Errorf("failed to create session, %s", err.Error())
 	}
 
-	extType := strings.TrimPrefix(ext, ".")
-	contentType := ""
-	switch extType {
-	case "jpg", "jpeg", "png":
-		contentType = "image/" + extType
-	case "svg":
-		contentType = "image/svg+xml"
-	case "js":
-		contentType = "application/javascript"
-	case "css":
-		contentType = "text/css"
-	case "map":
-		contentType = "application/json"
-	case "woff":
-		contentType = "application/font-woff"
-	case "woff2":
-		contentType = "application/font-woff2"
+	input := &s3.PutObjectInput{
+		Body:         object.Body,
+		Bucket:       aws.String(s.bucket),
+		Key:          aws.String(object.Key),
+		ContentType:  aws.String(object.ContentType),
+		CacheControl: aws.String(object.CacheControl),
+	}
+	if len(object.ContentEncoding) > 0 {
+		input.ContentEncoding = aws.String(object.ContentEncoding)
+	}
+	_, err = s3.New(newSession).PutObject(input)
+	if err != nil {
+		// the status of a response is kept by StatusCode, the network error of a request without
+		// response is only reachable through OrigErr, cdn.Retryable needs either of them
+		var aerr awserr.Error
+		if errors.As(err, &aerr) && aerr.OrigErr() != nil {
+			return fmt.Errorf("failed to put object, %s: %w", aerr.Message(), aerr.OrigErr())
+		}
+		return fmt.Errorf("failed to put object, %w", err)
 	}
+	return nil
+}
 
-	_, err = s3.New(newSession).PutObject(&s3.PutObjectInput{
-		Body:        file,
-		Bucket:      aws.String(s.bucket),
-		Key:         aws.String(key),
-		ContentType: aws.String(contentType),
+// GetObject returns the content of the object, cdn.ErrObjectNotExist when it does not exist
+func (s *Client) GetObject(key string) ([]byte, error) {
+	newSession, err := session.NewSession(s.s3Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session, %s", err.Error())
+	}
+	output, err := s3.New(newSession).GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to put object, %s", err.Error())
+		var aerr awserr.Error
+		if errors.As(err, &aerr) && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, cdn.ErrObjectNotExist
+		}
+		return nil, fmt.Errorf("failed to get object, %s", err.Error())
+	}
+	defer output.Body.Close()
+	return io.ReadAll(output.Body)
+}
+
+// ListObjects returns the keys of all the objects starting with the prefix
+func (s *Client) ListObjects(prefix string) ([]string, error) {
+	newSession, err := session.NewSession(s.s3Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session, %s", err.Error())
+	}
+	var keys []string
+	err = s3.New(newSession).ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	}, func(output *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, object := range output.Contents {
+			keys = append(keys, aws.StringValue(object.Key))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects, %s", err.Error())
+	}
+	return keys, nil
+}
+
+// DeleteObjects deletes the objects, at most deleteObjectsLimit of them by a request
+func (s *Client) DeleteObjects(keys []string) error {
+	newSession, err := session.NewSession(s.s3Config)
+	if err != nil {
+		return fmt.Errorf("failed to create session, %s", err.Error())
+	}
+	client := s3.New(newSession)
+	for batch := range slices.Chunk(keys, deleteObjectsLimit) {
+		objects := make([]*s3.ObjectIdentifier, 0, len(batch))
+		for _, key := range batch {
+			objects = append(objects, &s3.ObjectIdentifier{Key: aws.String(key)})
+		}
+		output, err := client.DeleteObjects(&s3.DeleteObjectsInput{
+			Bucket: aws.String(s.bucket),
+			Delete: &s3.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete objects, %s", err.Error())
+		}
+		if len(output.Errors) > 0 {
+			return fmt.Errorf("failed to delete object %s, %s",
+				aws.StringValue(output.Errors[0].Key), aws.StringValue(output.Errors[0].Message))
+		}
 	}
-	return err
+	return nil
 }
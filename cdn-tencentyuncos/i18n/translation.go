@@ -0,0 +1,61 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package i18n
+
+const (
+	InfoName        = "plugin.tencentyuncos_cdn.backend.info.name"
+	InfoDescription = "plugin.tencentyuncos_cdn.backend.info.description"
+
+	ConfigRegionTitle                = "plugin.tencentyuncos_cdn.backend.config.region.title"
+	ConfigRegionDescription          = "plugin.tencentyuncos_cdn.backend.config.region.description"
+	ConfigBucketNameTitle            = "plugin.tencentyuncos_cdn.backend.config.bucket_name.title"
+	ConfigBucketNameDescription      = "plugin.tencentyuncos_cdn.backend.config.bucket_name.description"
+	ConfigObjectKeyPrefixTitle       = "plugin.tencentyuncos_cdn.backend.config.object_key_prefix.title"
+	ConfigObjectKeyPrefixDescription = "plugin.tencentyuncos_cdn.backend.config.object_key_prefix.description"
+	ConfigSecretIdTitle              = "plugin.tencentyuncos_cdn.backend.config.secret_id.title"
+	ConfigSecretIdDescription        = "plugin.tencentyuncos_cdn.backend.config.secret_id.description"
+	ConfigSecretKeyTitle             = "plugin.tencentyuncos_cdn.backend.config.secret_key.title"
+	ConfigSecretKeyDescription       = "plugin.tencentyuncos_cdn.backend.config.secret_key.description"
+	ConfigVisitUrlPrefixTitle        = "plugin.tencentyuncos_cdn.backend.config.visit_url_prefix.title"
+	ConfigVisitUrlPrefixDescription  = "plugin.tencentyuncos_cdn.backend.config.visit_url_prefix.description"
+	ConfigMaxFileSizeTitle           = "plugin.tencentyuncos_cdn.backend.config.max_file_size.title"
+	ConfigMaxFileSizeDescription     = "plugin.tencentyuncos_cdn.backend.config.max_file_size.description"
+	ConfigCompressionTitle           = "plugin.tencentyuncos_cdn.backend.config.compression.title"
+	ConfigCompressionDescription     = "plugin.tencentyuncos_cdn.backend.config.compression.description"
+	ConfigCompressionOptionsNone     = "plugin.tencentyuncos_cdn.backend.config.compression.options.none"
+	ConfigCompressionOptionsGzip     = "plugin.tencentyuncos_cdn.backend.config.compression.options.gzip"
+	ConfigCompressionOptionsVariants = "plugin.tencentyuncos_cdn.backend.config.compression.options.variants"
+	ConfigVersionedTitle             = "plugin.tencentyuncos_cdn.backend.config.versioned.title"
+	ConfigVersionedDescription       = "plugin.tencentyuncos_cdn.backend.config.versioned.description"
+	ConfigKeepReleasesTitle          = "plugin.tencentyuncos_cdn.backend.config.keep_releases.title"
+	ConfigKeepReleasesDescription    = "plugin.tencentyuncos_cdn.backend.config.keep_releases.description"
+	ConfigCleanupTitle               = "plugin.tencentyuncos_cdn.backend.config.cleanup.title"
+	ConfigCleanupDescription         = "plugin.tencentyuncos_cdn.backend.config.cleanup.description"
+	ConfigCleanupOptionsOff          = "plugin.tencentyuncos_cdn.backend.config.cleanup.options.off"
+	ConfigCleanupOptionsDryRun       = "plugin.tencentyuncos_cdn.backend.config.cleanup.options.dry_run"
+	ConfigCleanupOptionsDelete       = "plugin.tencentyuncos_cdn.backend.config.cleanup.options.delete"
+	ConfigUnversionedTitle           = "plugin.tencentyuncos_cdn.backend.config.clean_unversioned.title"
+	ConfigUnversionedDescription     = "plugin.tencentyuncos_cdn.backend.config.clean_unversioned.description"
+
+	ErrMisStorageConfig    = "plugin.tencentyuncos_cdn.backend.err.mis_storage_config"
+	ErrUnsupportedFileType = "plugin.tencentyuncos_cdn.backend.err.unsupported_file_type"
+	ErrOverFileSizeLimit   = "plugin.tencentyuncos_cdn.backend.err.over_file_size_limit"
+	ErrUploadFileFailed    = "plugin.tencentyuncos_cdn.backend.err.upload_file_failed"
+)
@@ -0,0 +1,462 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package tencentyuncos
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/apache/answer-plugins/cdn-tencentyuncos/i18n"
+	"github.com/apache/answer-plugins/util"
+	"github.com/apache/answer-plugins/util/cdn"
+	"github.com/apache/answer/plugin"
+	"github.com/apache/answer/ui"
+	"github.com/segmentfault/pacman/log"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+var (
+	staticPath = os.Getenv("ANSWER_STATIC_PATH")
+	// staticPrefix switches to the CDN once every static file is uploaded
+	staticPrefix = &cdn.StaticPrefix{}
+)
+
+//go:embed  info.yaml
+var Info embed.FS
+
+const (
+	// 10MB
+	defaultMaxFileSize int64 = 10 * 1024 * 1024
+	// defaultKeepReleases is the number of previous releases kept by the cleanup
+	defaultKeepReleases = 2
+	// deleteObjectsLimit is the number of objects deleted by a request at most
+	deleteObjectsLimit = 1000
+)
+
+type CDN struct {
+	Config *CDNConfig
+}
+
+type CDNConfig struct {
+	Region           string `json:"region"`
+	BucketName       string `json:"bucket_name"`
+	ObjectKeyPrefix  string `json:"object_key_prefix"`
+	SecretID         string `json:"secret_id"`
+	SecretKey        string `json:"secret_key"`
+	VisitUrlPrefix   string `json:"visit_url_prefix"`
+	MaxFileSize      string `json:"max_file_size"`
+	Compression      string `json:"compression"`
+	Versioned        bool   `json:"versioned"`
+	KeepReleases     string `json:"keep_releases"`
+	Cleanup          string `json:"cleanup"`
+	CleanUnversioned bool   `json:"clean_unversioned"`
+}
+
+// cosBucket uploads the static files to a tencent cloud cos bucket
+type cosBucket struct {
+	client *cos.Client
+}
+
+func (b *cosBucket) PutObject(object *cdn.Object) error {
+	_, err := b.client.Object.Put(context.Background(), object.Key, object.Body, &cos.ObjectPutOptions{
+		ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{
+			ContentType:     object.ContentType,
+			CacheControl:    object.CacheControl,
+			ContentEncoding: object.ContentEncoding,
+			ContentLength:   object.Size,
+		},
+	})
+	var rerr *cos.ErrorResponse
+	if errors.As(err, &rerr) && rerr.Response != nil {
+		return &cdn.StatusError{Status: rerr.Response.StatusCode, Err: err}
+	}
+	return err
+}
+
+func (b *cosBucket) GetObject(key string) ([]byte, error) {
+	resp, err := b.client.Object.Get(context.Background(), key, nil)
+	if err != nil {
+		if cos.IsNotFoundError(err) {
+			return nil, cdn.ErrObjectNotExist
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+func (b *cosBucket) ListObjects(prefix string) ([]string, error) {
+	var keys []string
+	marker := ""
+	for {
+		result, _, err := b.client.Bucket.Get(context.Background(), &cos.BucketGetOptions{
+			Prefix:  prefix,
+			Marker:  marker,
+			MaxKeys: deleteObjectsLimit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range result.Contents {
+			keys = append(keys, object.Key)
+		}
+		if !result.IsTruncated {
+			return keys, nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+func (b *cosBucket) DeleteObjects(keys []string) error {
+	for batch := range slices.Chunk(keys, deleteObjectsLimit) {
+		objects := make([]cos.Object, 0, len(batch))
+		for _, key := range batch {
+			objects = append(objects, cos.Object{Key: key})
+		}
+		result, _, err := b.client.Object.DeleteMulti(context.Background(), &cos.ObjectDeleteMultiOptions{
+			Quiet:   true,
+			Objects: objects,
+		})
+		if err != nil {
+			return err
+		}
+		if len(result.Errors) > 0 {
+			return fmt.Errorf("delete object %s failed: %s", result.Errors[0].Key, result.Errors[0].Message)
+		}
+	}
+	return nil
+}
+
+func init() {
+	plugin.Register(&CDN{
+		Config: &CDNConfig{},
+	})
+}
+
+func (c *CDN) Info() plugin.Info {
+	info := util.Info{}
+	info.GetInfo(Info)
+	return plugin.Info{
+		Name:        plugin.MakeTranslator(i18n.InfoName),
+		SlugName:    info.SlugName,
+		Description: plugin.MakeTranslator(i18n.InfoDescription),
+		Author:      info.Author,
+		Version:     info.Version,
+		Link:        info.Link,
+	}
+}
+
+// GetStaticPrefix get static prefix
+func (c *CDN) GetStaticPrefix() string {
+	return staticPrefix.Get()
+}
+
+// scanFiles uploads all the static files in the build directory, the static prefix switches to
+// the CDN when all of them are uploaded and the sync is still the latest one
+func (c *CDN) scanFiles(generation int64, config *CDNConfig) {
+	client, err := config.client()
+	if err != nil {
+		log.Error(plugin.MakeTranslator(i18n.ErrMisStorageConfig), err)
+		return
+	}
+	build, err := cdn.BuildFS(ui.Build, staticPath)
+	if err != nil {
+		log.Error("failed: open static files:", err)
+		return
+	}
+	keyPrefix, release, err := config.keyPrefix(build)
+	if err != nil {
+		log.Error("failed: read static files:", err)
+		return
+	}
+	provider := &cosBucket{client: client}
+	syncer := &cdn.Syncer{
+		Provider:       provider,
+		VisitURLPrefix: config.VisitUrlPrefix,
+		KeyPrefix:      keyPrefix,
+		MaxFileSize:    config.maxFileSizeLimit(),
+		FileTypes:      plugin.DefaultCDNFileType,
+		Compression:    config.Compression,
+		CheckAvailable: true,
+		Logf:           log.Warnf,
+		Progress: func(uploaded, total int) {
+			log.Infof("upload static files: %d/%d", uploaded, total)
+		},
+	}
+	result, err := syncer.Sync(build)
+	if err != nil {
+		log.Error("failed: upload static files:", err)
+		return
+	}
+	staticPrefix.Complete(generation, config.VisitUrlPrefix+keyPrefix)
+	log.Infof("complete: upload static files, %d uploaded, %d unchanged, %d skipped",
+		result.Uploaded, result.Unchanged, result.Skipped)
+
+	if config.Versioned {
+		cleanReleases(provider, config, release)
+	}
+}
+
+// cleanReleases records the release and removes the releases before the kept ones
+func cleanReleases(provider cdn.CleanupProvider, config *CDNConfig, release string) {
+	cleaner := &cdn.Cleaner{
+		Provider:         provider,
+		KeyPrefix:        config.ObjectKeyPrefix,
+		Keep:             config.keepReleases(),
+		Mode:             config.Cleanup,
+		Logf:             log.Infof,
+		CleanUnversioned: config.CleanUnversioned,
+	}
+	result, err := cleaner.Clean(release)
+	if err != nil {
+		log.Error("failed: clean up releases:", err)
+		return
+	}
+	if len(result.Removed) == 0 && result.Unversioned == 0 {
+		return
+	}
+	if config.Cleanup == cdn.CleanupDryRun {
+		log.Infof("dry run: clean up releases %v and %d unversioned objects, %d objects would be deleted",
+			result.Removed, result.Unversioned, result.Objects)
+		return
+	}
+	log.Infof("complete: clean up releases %v and %d unversioned objects, %d objects deleted",
+		result.Removed, result.Unversioned, result.Objects)
+}
+
+func (cfg *CDNConfig) client() (*cos.Client, error) {
+	bucketURL, err := url.Parse(fmt.Sprintf("https://%s.cos.%s.myqcloud.com", cfg.BucketName, cfg.Region))
+	if err != nil {
+		return nil, err
+	}
+	return cos.NewClient(&cos.BaseURL{BucketURL: bucketURL}, &http.Client{
+		Transport: &cos.AuthorizationTransport{
+			SecretID:  cfg.SecretID,
+			SecretKey: cfg.SecretKey,
+		},
+	}), nil
+}
+
+// keyPrefix returns the prefix the static files are uploaded under, with the release they belong to when versioned
+func (cfg *CDNConfig) keyPrefix(build fs.FS) (keyPrefix, release string, err error) {
+	if !cfg.Versioned {
+		return cfg.ObjectKeyPrefix, "", nil
+	}
+	if release, err = cdn.Release(build); err != nil {
+		return "", "", err
+	}
+	return cfg.ObjectKeyPrefix + release + "/", release, nil
+}
+
+func (cfg *CDNConfig) keepReleases() int {
+	if len(cfg.KeepReleases) == 0 {
+		return defaultKeepReleases
+	}
+	keep, err := strconv.Atoi(cfg.KeepReleases)
+	if err != nil || keep < 0 {
+		return defaultKeepReleases
+	}
+	return keep
+}
+
+func (cfg *CDNConfig) maxFileSizeLimit() int64 {
+	if len(cfg.MaxFileSize) == 0 {
+		return defaultMaxFileSize
+	}
+	limit, _ := strconv.Atoi(cfg.MaxFileSize)
+	if limit <= 0 {
+		return defaultMaxFileSize
+	}
+	return int64(limit) * 1024 * 1024
+}
+
+func (c *CDN) ConfigFields() []plugin.ConfigField {
+	return []plugin.ConfigField{
+		{
+			Name:        "region",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigRegionTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigRegionDescription),
+			Required:    true,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.Region,
+		},
+		{
+			Name:        "bucket_name",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigBucketNameTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigBucketNameDescription),
+			Required:    true,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.BucketName,
+		},
+		{
+			Name:        "object_key_prefix",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigObjectKeyPrefixTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigObjectKeyPrefixDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.ObjectKeyPrefix,
+		},
+		{
+			Name:        "secret_id",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigSecretIdTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigSecretIdDescription),
+			Required:    true,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.SecretID,
+		},
+		{
+			Name:        "secret_key",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigSecretKeyTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigSecretKeyDescription),
+			Required:    true,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.SecretKey,
+		},
+		{
+			Name:        "visit_url_prefix",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigVisitUrlPrefixTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigVisitUrlPrefixDescription),
+			Required:    true,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeText,
+			},
+			Value: c.Config.VisitUrlPrefix,
+		},
+		{
+			Name:        "max_file_size",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigMaxFileSizeTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigMaxFileSizeDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.MaxFileSize,
+		},
+		{
+			Name:        "compression",
+			Type:        plugin.ConfigTypeSelect,
+			Title:       plugin.MakeTranslator(i18n.ConfigCompressionTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigCompressionDescription),
+			Required:    false,
+			Options: []plugin.ConfigFieldOption{
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigCompressionOptionsNone),
+					Value: cdn.CompressionNone,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigCompressionOptionsGzip),
+					Value: cdn.CompressionGzip,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigCompressionOptionsVariants),
+					Value: cdn.CompressionVariants,
+				},
+			},
+			Value: c.Config.Compression,
+		},
+		{
+			Name:  "versioned",
+			Type:  plugin.ConfigTypeSwitch,
+			Title: plugin.MakeTranslator(i18n.ConfigVersionedTitle),
+			Value: c.Config.Versioned,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				Label: plugin.MakeTranslator(i18n.ConfigVersionedDescription),
+			},
+		},
+		{
+			Name:        "keep_releases",
+			Type:        plugin.ConfigTypeInput,
+			Title:       plugin.MakeTranslator(i18n.ConfigKeepReleasesTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigKeepReleasesDescription),
+			Required:    false,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				InputType: plugin.InputTypeNumber,
+			},
+			Value: c.Config.KeepReleases,
+		},
+		{
+			Name:        "cleanup",
+			Type:        plugin.ConfigTypeSelect,
+			Title:       plugin.MakeTranslator(i18n.ConfigCleanupTitle),
+			Description: plugin.MakeTranslator(i18n.ConfigCleanupDescription),
+			Required:    false,
+			Options: []plugin.ConfigFieldOption{
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigCleanupOptionsOff),
+					Value: cdn.CleanupOff,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigCleanupOptionsDryRun),
+					Value: cdn.CleanupDryRun,
+				},
+				{
+					Label: plugin.MakeTranslator(i18n.ConfigCleanupOptionsDelete),
+					Value: cdn.CleanupDelete,
+				},
+			},
+			Value: c.Config.Cleanup,
+		},
+		{
+			Name:  "clean_unversioned",
+			Type:  plugin.ConfigTypeSwitch,
+			Title: plugin.MakeTranslator(i18n.ConfigUnversionedTitle),
+			Value: c.Config.CleanUnversioned,
+			UIOptions: plugin.ConfigFieldUIOptions{
+				Label: plugin.MakeTranslator(i18n.ConfigUnversionedDescription),
+			},
+		},
+	}
+}
+
+func (c *CDN) ConfigReceiver(config []byte) error {
+	cfg := &CDNConfig{}
+	_ = json.Unmarshal(config, cfg)
+	c.Config = cfg
+
+	go c.scanFiles(staticPrefix.Begin(), c.Config)
+	return nil
+}
@@ -0,0 +1,161 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package tencentyuncos
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/apache/answer-plugins/util/cdn"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+func TestCDNConfig(t *testing.T) {
+	cfg := &CDNConfig{}
+	err := json.Unmarshal([]byte(`{"region":"ap-guangzhou","bucket_name":"answer-1250000000",`+
+		`"object_key_prefix":"answer/","max_file_size":"20","keep_releases":"0","versioned":true,"cleanup":"dry_run"}`), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.maxFileSizeLimit() != 20*1024*1024 || cfg.keepReleases() != 0 || !cfg.Versioned || cfg.Cleanup != cdn.CleanupDryRun {
+		t.Errorf("config = %+v", cfg)
+	}
+	client, err := cfg.client()
+	if err != nil || client.BaseURL.BucketURL.String() != "https://answer-1250000000.cos.ap-guangzhou.myqcloud.com" {
+		t.Errorf("client() = %v, %v", client, err)
+	}
+
+	for _, value := range []string{"", "abc", "-1"} {
+		cfg = &CDNConfig{MaxFileSize: value, KeepReleases: value}
+		if cfg.maxFileSizeLimit() != defaultMaxFileSize || cfg.keepReleases() != defaultKeepReleases {
+			t.Errorf("defaults of %q = %d, %d", value, cfg.maxFileSizeLimit(), cfg.keepReleases())
+		}
+	}
+}
+
+func TestCDNConfig_KeyPrefix(t *testing.T) {
+	build := fstest.MapFS{
+		"index.html":        {Data: []byte("<html></html>")},
+		"static/js/main.js": {Data: []byte("console.log(1);")},
+	}
+	cfg := &CDNConfig{ObjectKeyPrefix: "answer/", VisitUrlPrefix: "https://cdn.example.com/"}
+	keyPrefix, release, err := cfg.keyPrefix(build)
+	if err != nil || keyPrefix != "answer/" || release != "" {
+		t.Errorf("keyPrefix() = %s, %s, %v", keyPrefix, release, err)
+	}
+
+	cfg.Versioned = true
+	want, _ := cdn.Release(build)
+	keyPrefix, release, err = cfg.keyPrefix(build)
+	if err != nil || release != want || keyPrefix != "answer/"+want+"/" {
+		t.Errorf("versioned keyPrefix() = %s, %s, %v", keyPrefix, release, err)
+	}
+	// the static prefix of the versioned upload is the release under the access url prefix
+	if prefix := cfg.VisitUrlPrefix + keyPrefix; prefix != "https://cdn.example.com/answer/"+want+"/" {
+		t.Errorf("static prefix = %s", prefix)
+	}
+}
+
+func TestCosBucket(t *testing.T) {
+	var put http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/answer/static/js/main.js":
+			put = r.Header.Clone()
+			body, _ := io.ReadAll(r.Body)
+			if string(body) != "console.log(1);" {
+				t.Errorf("body = %s", body)
+			}
+			// the sdk verifies the checksum of the upload
+			w.Header().Set("x-cos-hash-crc64ecma", strconv.FormatUint(crc64.Checksum(body, crc64.MakeTable(crc64.ECMA)), 10))
+		case r.Method == http.MethodGet && r.URL.Path == "/answer/asset-manifest.json":
+			_, _ = fmt.Fprint(w, `{"main.js":"static/js/main.js"}`)
+		case r.URL.Path == "/answer/busy.js":
+			writeError(w, http.StatusServiceUnavailable, "SlowDown")
+		case r.URL.Path == "/answer/denied.js":
+			writeError(w, http.StatusForbidden, "AccessDenied")
+		default:
+			writeError(w, http.StatusNotFound, "NoSuchKey")
+		}
+	}))
+	defer server.Close()
+
+	bucketURL, _ := url.Parse(server.URL)
+	client := cos.NewClient(&cos.BaseURL{BucketURL: bucketURL}, http.DefaultClient)
+	client.Conf.RetryOpt.Count = 1
+	b := &cosBucket{client: client}
+
+	body := []byte("console.log(1);")
+	err := b.PutObject(&cdn.Object{
+		Key:             "answer/static/js/main.js",
+		Size:            int64(len(body)),
+		Body:            bytes.NewReader(body),
+		ContentType:     "application/javascript",
+		CacheControl:    "public, max-age=31536000, immutable",
+		ContentEncoding: "br",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if put.Get("Content-Type") != "application/javascript" || put.Get("Cache-Control") != "public, max-age=31536000, immutable" ||
+		put.Get("Content-Encoding") != "br" || put.Get("Content-Length") != "15" {
+		t.Errorf("headers = %v", put)
+	}
+
+	// the status of a failed upload tells whether it is retried
+	err = b.PutObject(&cdn.Object{Key: "answer/busy.js", Body: bytes.NewReader(body)})
+	var serr *cdn.StatusError
+	if !errors.As(err, &serr) || serr.Status != http.StatusServiceUnavailable || !cdn.Retryable(err) {
+		t.Errorf("PutObject() = %v, want a retryable status error", err)
+	}
+	err = b.PutObject(&cdn.Object{Key: "answer/denied.js", Body: bytes.NewReader(body)})
+	if !errors.As(err, &serr) || serr.Status != http.StatusForbidden || cdn.Retryable(err) {
+		t.Errorf("PutObject() = %v, want a status error which is not retried", err)
+	}
+
+	data, err := b.GetObject("answer/asset-manifest.json")
+	if err != nil || string(data) != `{"main.js":"static/js/main.js"}` {
+		t.Errorf("GetObject() = %s, %v", data, err)
+	}
+	if _, err = b.GetObject("answer/" + cdn.ManifestName); !errors.Is(err, cdn.ErrObjectNotExist) {
+		t.Errorf("GetObject() of a missing object = %v", err)
+	}
+	if _, err = b.GetObject("answer/denied.js"); err == nil || errors.Is(err, cdn.ErrObjectNotExist) {
+		t.Errorf("GetObject() of a denied object = %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>%s</Code><Message>%s</Message></Error>`,
+		code, strings.ToLower(code))
+}
@@ -25,7 +25,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"math/rand"
 	"regexp"
 	"strings"
 	"time"
@@ -33,6 +32,7 @@ import (
 
 	"github.com/apache/answer-plugins/connector-basic/i18n"
 	"github.com/apache/answer-plugins/util"
+	"github.com/apache/answer-plugins/util/oauthstate"
 	"github.com/apache/answer/pkg/checker"
 	"github.com/apache/answer/plugin"
 	"github.com/segmentfault/pacman/log"
@@ -72,16 +72,6 @@ type ConnectorConfig struct {
 	LogoSVG string `json:"logo_svg"`
 }
 
-var base64chars = strings.Split("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_", "")
-
-func randomString(length int) (ret string) {
-	l := len(base64chars)
-	for i := 0; i < length; i++ {
-		ret = ret + base64chars[rand.Intn(l)]
-	}
-	return
-}
-
 func init() {
 	plugin.Register(&Connector{
 		Config: &ConnectorConfig{},
@@ -128,11 +118,18 @@ func (g *Connector) ConnectorSender(ctx *plugin.GinContext, receiverURL string)
 		RedirectURL: receiverURL,
 		Scopes:      strings.Split(g.Config.Scope, ","),
 	}
-	state := randomString(24)
+	state, err := g.stateCookie().Issue(ctx.Writer, ctx.Request)
+	if err != nil {
+		log.Errorf("failed generating oauth state: %s", err)
+		return ""
+	}
 	return oauth2Config.AuthCodeURL(state)
 }
 
 func (g *Connector) ConnectorReceiver(ctx *plugin.GinContext, receiverURL string) (userInfo plugin.ExternalLoginUserInfo, err error) {
+	if err = g.stateCookie().Verify(ctx.Writer, ctx.Request, ctx.Query("state")); err != nil {
+		return userInfo, fmt.Errorf("invalid oauth state: %w", err)
+	}
 	code := ctx.Query("code")
 	// Exchange code for token
 	oauth2Config := &oauth2.Config{
@@ -197,6 +194,10 @@ func (g *Connector) ConnectorReceiver(ctx *plugin.GinContext, receiverURL string
 	return userInfo, nil
 }
 
+func (g *Connector) stateCookie() *oauthstate.Cookie {
+	return &oauthstate.Cookie{Name: g.ConnectorSlugName(), Secret: g.Config.ClientSecret}
+}
+
 func (g *Connector) formatUserInfo(userInfo plugin.ExternalLoginUserInfo) (
 	userInfoFormatted plugin.ExternalLoginUserInfo) {
 	userInfoFormatted = userInfo
@@ -25,9 +25,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 
 	"github.com/apache/answer-plugins/connector-dingtalk/i18n"
 	"github.com/apache/answer-plugins/util"
+	"github.com/apache/answer-plugins/util/oauthstate"
 	"github.com/apache/answer/plugin"
 	"github.com/segmentfault/pacman/log"
 )
@@ -101,13 +103,22 @@ func (g *Connector) ConnectorSlugName() string {
 }
 
 func (g *Connector) ConnectorSender(ctx *plugin.GinContext, receiverURL string) (redirectURL string) {
-	return fmt.Sprintf("%s?redirect_uri=%s&response_type=code&client_id=%s&scope=Contact.User.Read&state=state&prompt=consent",
-		AuthorizeURL, receiverURL, g.Config.ClientID)
+	state, err := g.stateCookie().Issue(ctx.Writer, ctx.Request)
+	if err != nil {
+		log.Errorf("fail to generate oauth state : %s", err)
+		return ""
+	}
+	return fmt.Sprintf("%s?redirect_uri=%s&response_type=code&client_id=%s&scope=Contact.User.Read&state=%s&prompt=consent",
+		AuthorizeURL, receiverURL, g.Config.ClientID, url.QueryEscape(state))
 }
 
 func (g *Connector) ConnectorReceiver(ctx *plugin.GinContext, receiverURL string) (userInfo plugin.ExternalLoginUserInfo, err error) {
 
-	// 1. get code
+	// 1. verify state and get code
+	if err = g.stateCookie().Verify(ctx.Writer, ctx.Request, ctx.Query("state")); err != nil {
+		log.Errorf("fail to verify oauth state : %s", err)
+		return plugin.ExternalLoginUserInfo{}, fmt.Errorf("invalid oauth state: %w", err)
+	}
 	code := ctx.Query("code")
 	log.Debugf("code: %s", code)
 
@@ -133,6 +144,10 @@ func (g *Connector) ConnectorReceiver(ctx *plugin.GinContext, receiverURL string
 	return user, nil
 }
 
+func (g *Connector) stateCookie() *oauthstate.Cookie {
+	return &oauthstate.Cookie{Name: g.ConnectorSlugName(), Secret: g.Config.ClientSecret}
+}
+
 func getToken(url string, body map[string]string) (token string, err error) {
 	jsonBody, _ := json.Marshal(body)
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
@@ -28,6 +28,7 @@ import (
 
 	"github.com/apache/answer-plugins/connector-github/i18n"
 	"github.com/apache/answer-plugins/util"
+	"github.com/apache/answer-plugins/util/oauthstate"
 	"github.com/apache/answer/plugin"
 	"github.com/google/go-github/v50/github"
 	"github.com/segmentfault/pacman/log"
@@ -87,10 +88,18 @@ func (g *Connector) ConnectorSender(ctx *plugin.GinContext, receiverURL string)
 		RedirectURL:  receiverURL,
 		Scopes:       []string{"user:email"},
 	}
-	return oauth2Config.AuthCodeURL("state")
+	state, err := g.stateCookie().Issue(ctx.Writer, ctx.Request)
+	if err != nil {
+		log.Errorf("failed generating oauth state: %s", err)
+		return ""
+	}
+	return oauth2Config.AuthCodeURL(state)
 }
 
 func (g *Connector) ConnectorReceiver(ctx *plugin.GinContext, receiverURL string) (userInfo plugin.ExternalLoginUserInfo, err error) {
+	if err = g.stateCookie().Verify(ctx.Writer, ctx.Request, ctx.Query("state")); err != nil {
+		return userInfo, fmt.Errorf("invalid oauth state: %w", err)
+	}
 	code := ctx.Query("code")
 	// Exchange code for token
 	oauth2Config := &oauth2.Config{
@@ -129,6 +138,10 @@ func (g *Connector) ConnectorReceiver(ctx *plugin.GinContext, receiverURL string
 	return userInfo, nil
 }
 
+func (g *Connector) stateCookie() *oauthstate.Cookie {
+	return &oauthstate.Cookie{Name: g.ConnectorSlugName(), Secret: g.Config.ClientSecret}
+}
+
 func (g *Connector) guaranteeEmail(email string, accessToken string) string {
 	client := oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: accessToken},
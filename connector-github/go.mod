@@ -59,3 +59,5 @@ require (
 	xorm.io/builder v0.3.13 // indirect
 	xorm.io/xorm v1.3.2 // indirect
 )
+
+replace github.com/apache/answer-plugins/util => ../util
@@ -0,0 +1,385 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+// Package cdn uploads the static files of the answer ui build to the storage of a CDN.
+// The CDN plugins only implement Provider, walking the build, rewriting the asset paths
+// to the CDN and checking the uploaded objects are shared.
+//
+// The hashes of the uploaded files are stored in a manifest object next to them,
+// so only the files changed since the last sync are uploaded again.
+package cdn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// Object is a file of the ui build to be stored by the provider
+type Object struct {
+	// Key is the object key, the key prefix included
+	Key string
+	// Path is the slash separated path of the file in the ui build, e.g. static/js/main.js
+	Path string
+	Size int64
+	Body io.ReadSeeker
+	// ContentType, CacheControl and ContentEncoding are the headers the object is served with
+	ContentType     string
+	CacheControl    string
+	ContentEncoding string
+
+	// suffix is appended to the key of the compressed variants
+	suffix string
+}
+
+func (o *Object) setContent(content []byte) {
+	o.Size = int64(len(content))
+	o.Body = bytes.NewReader(content)
+}
+
+// ManifestName is the name of the manifest object, it is stored under the key prefix
+const ManifestName = "answer-cdn-manifest.json"
+
+const (
+	// defaultCheckSample is the number of objects checked when CheckSample is not set
+	defaultCheckSample = 5
+	// defaultWorkers is the number of concurrent uploads when Workers is not set
+	defaultWorkers = 8
+	// defaultRetries is the number of retries of a failed upload when Retries is not set
+	defaultRetries = 3
+	// defaultRetryDelay is the wait before the first retry when RetryDelay is not set, it doubles after every retry
+	defaultRetryDelay = 500 * time.Millisecond
+)
+
+// ErrObjectNotExist is returned by Provider.GetObject when there is no object with the key
+var ErrObjectNotExist = errors.New("object does not exist")
+
+// Provider stores objects in the storage behind the CDN
+type Provider interface {
+	PutObject(object *Object) error
+	// GetObject returns the content of the object, or ErrObjectNotExist
+	GetObject(key string) ([]byte, error)
+}
+
+// Manifest records the sha256 of the content of every synced file by its path in the build
+type Manifest struct {
+	Files map[string]string `json:"files"`
+}
+
+// Result counts the files of a sync
+type Result struct {
+	Uploaded  int
+	Unchanged int
+	Skipped   int
+	// Changed are the keys of the objects uploaded over the ones of a previous sync,
+	// the copies cached by the CDN are stale. Without the manifest of a previous sync
+	// every uploaded object may overwrite one, so all of them are changed.
+	Changed []string
+}
+
+// Syncer uploads the ui build to a provider
+type Syncer struct {
+	Provider Provider
+	// VisitURLPrefix is the address the objects are served from, it ends with "/"
+	VisitURLPrefix string
+	// KeyPrefix is prepended to the path of every object, it ends with "/" when not empty
+	KeyPrefix string
+	// MaxFileSize is the size in bytes of the largest file uploaded, larger files are skipped
+	MaxFileSize int64
+	// FileTypes are the extensions uploaded, other files are skipped
+	FileTypes map[string]bool
+	// Compression is one of CompressionNone, CompressionGzip and CompressionVariants, empty means none
+	Compression string
+	// CheckAvailable sends HEAD requests for a sample of the synced objects to the visit url
+	CheckAvailable bool
+	// CheckSample is the number of objects checked, the objects uploaded by this sync come first
+	CheckSample int
+	// Workers is the number of files uploaded concurrently
+	Workers int
+	// Retries is the number of retries of an upload failed for a transient reason, see Retryable,
+	// waiting RetryDelay doubled after every retry
+	Retries    int
+	RetryDelay time.Duration
+	// Logf reports the skipped files and the retries, it is optional
+	Logf func(format string, args ...any)
+	// Progress reports the number of the uploaded files about every tenth of them, it is optional
+	Progress func(uploaded, total int)
+}
+
+// file is a changed file of the build to be uploaded
+type file struct {
+	path    string
+	hash    string
+	content []byte
+	// overwritten is set when the previous sync uploaded the file with another content
+	overwritten bool
+	// keys are the keys of the uploaded objects, the compressed variants included
+	keys []string
+}
+
+// replacement replaces old with new in the content of a file, an empty new is the static prefix on the CDN
+type replacement struct {
+	old string
+	new string
+}
+
+// BuildFS returns the ui build served by answer, the static path when it is set or the embedded build
+func BuildFS(embedded fs.FS, staticPath string) (fs.FS, error) {
+	if len(staticPath) > 0 {
+		return os.DirFS(staticPath), nil
+	}
+	return fs.Sub(embedded, "build")
+}
+
+// Sync uploads the files of the build changed since the last sync, the root of the build is the directory
+// containing index.html. The failed uploads are retried, the sync fails when a file still fails to upload.
+// The files uploaded before the failure are recorded in the manifest, so the next sync resumes with the others.
+func (s *Syncer) Sync(build fs.FS) (*Result, error) {
+	previous, loaded := s.loadManifest()
+	current := &Manifest{Files: make(map[string]string)}
+	result := &Result{}
+	var changed []*file
+	var unchanged []string
+
+	err := fs.WalkDir(build, ".", func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		content, skipped, err := s.readFile(build, filePath)
+		if err != nil {
+			return err
+		}
+		if skipped {
+			result.Skipped++
+			return nil
+		}
+		hash := s.hash(filePath, content)
+		if previous.Files[filePath] == hash {
+			current.Files[filePath] = hash
+			result.Unchanged++
+			unchanged = append(unchanged, filePath)
+			return nil
+		}
+		_, overwritten := previous.Files[filePath]
+		overwritten = overwritten || !loaded
+		changed = append(changed, &file{path: filePath, hash: hash, content: content, overwritten: overwritten})
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	uploaded, err := s.upload(changed)
+	result.Uploaded = len(uploaded)
+	for _, f := range uploaded {
+		current.Files[f.path] = f.hash
+		if f.overwritten {
+			result.Changed = append(result.Changed, f.keys...)
+		}
+	}
+	if err != nil {
+		if len(uploaded) > 0 {
+			if merr := s.putManifest(current); merr != nil {
+				s.logf("%v", merr)
+			}
+		}
+		return result, err
+	}
+
+	if s.CheckAvailable {
+		paths := make([]string, 0, len(uploaded))
+		for _, f := range uploaded {
+			paths = append(paths, f.path)
+		}
+		for _, filePath := range s.checkSample(paths, unchanged) {
+			if err = s.checkAvailable(s.KeyPrefix + filePath); err != nil {
+				return result, err
+			}
+		}
+	}
+	if result.Uploaded == 0 && len(previous.Files) == len(current.Files) {
+		return result, nil
+	}
+	return result, s.putManifest(current)
+}
+
+// putManifest uploads the manifest, it is never compressed as it is read back by the next sync
+func (s *Syncer) putManifest(manifest *Manifest) error {
+	data, _ := json.Marshal(manifest)
+	return s.putObject(&Object{
+		Key:          s.KeyPrefix + ManifestName,
+		Path:         ManifestName,
+		ContentType:  ContentType(ManifestName, data),
+		CacheControl: "no-cache",
+	}, data)
+}
+
+// readFile returns the rewritten content of the file, unsupported and oversized files are skipped
+func (s *Syncer) readFile(build fs.FS, filePath string) (content []byte, skipped bool, err error) {
+	if !s.FileTypes[strings.ToLower(path.Ext(filePath))] {
+		s.logf("skip %s: unsupported file type", filePath)
+		return nil, true, nil
+	}
+	info, err := fs.Stat(build, filePath)
+	if err != nil {
+		return nil, false, err
+	}
+	if s.MaxFileSize > 0 && info.Size() > s.MaxFileSize {
+		s.logf("skip %s: size %d is over the limit %d", filePath, info.Size(), s.MaxFileSize)
+		return nil, true, nil
+	}
+	content, err = fs.ReadFile(build, filePath)
+	if err != nil {
+		return nil, false, fmt.Errorf("read %s failed: %w", filePath, err)
+	}
+	return s.Rewrite(filePath, content), false, nil
+}
+
+// put uploads the file, with the compressed variants when enabled, and returns the keys of the objects
+func (s *Syncer) put(filePath string, content []byte) ([]string, error) {
+	objects, err := encode(s.Compression, filePath, content)
+	if err != nil {
+		return nil, fmt.Errorf("compress %s failed: %w", filePath, err)
+	}
+	keys := make([]string, 0, len(objects))
+	for _, object := range objects {
+		object.Key = s.KeyPrefix + filePath + object.suffix
+		if err = s.retry(object); err != nil {
+			return nil, fmt.Errorf("upload %s failed: %w", object.Key, err)
+		}
+		keys = append(keys, object.Key)
+	}
+	return keys, nil
+}
+
+func (s *Syncer) putObject(object *Object, content []byte) error {
+	object.setContent(content)
+	if err := s.retry(object); err != nil {
+		return fmt.Errorf("upload %s failed: %w", object.Key, err)
+	}
+	return nil
+}
+
+// loadManifest returns the manifest of the last sync and whether it was loaded,
+// it is empty when missing or unreadable
+func (s *Syncer) loadManifest() (*Manifest, bool) {
+	manifest := &Manifest{}
+	data, err := s.Provider.GetObject(s.KeyPrefix + ManifestName)
+	if err != nil {
+		if !errors.Is(err, ErrObjectNotExist) {
+			s.logf("read manifest failed, all files are uploaded: %v", err)
+		}
+		return &Manifest{Files: make(map[string]string)}, false
+	}
+	if err = json.Unmarshal(data, manifest); err != nil || manifest.Files == nil {
+		s.logf("parse manifest failed, all files are uploaded: %v", err)
+		return &Manifest{Files: make(map[string]string)}, false
+	}
+	return manifest, true
+}
+
+// checkSample picks the objects to check, the uploaded ones first
+func (s *Syncer) checkSample(uploaded, unchanged []string) []string {
+	size := s.CheckSample
+	if size <= 0 {
+		size = defaultCheckSample
+	}
+	sample := append(append(make([]string, 0, len(uploaded)+len(unchanged)), uploaded...), unchanged...)
+	if len(sample) > size {
+		sample = sample[:size]
+	}
+	return sample
+}
+
+// hash covers the headers and the compression as well, changing them uploads the file again
+func (s *Syncer) hash(filePath string, content []byte) string {
+	h := sha256.New()
+	h.Write([]byte(s.Compression + "\n" + ContentType(filePath, content) + "\n" + CacheControl(filePath) + "\n"))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Rewrite points the asset paths in the entry files of the build to the CDN
+func (s *Syncer) Rewrite(filePath string, content []byte) []byte {
+	replacements := rewrites(filePath)
+	if len(replacements) == 0 {
+		return content
+	}
+	staticPrefix := "\"" + strings.TrimSuffix(s.VisitURLPrefix+s.KeyPrefix, "/") + "/static"
+	res := string(content)
+	for _, r := range replacements {
+		newStr := r.new
+		if newStr == "" {
+			newStr = staticPrefix
+		}
+		res = strings.ReplaceAll(res, r.old, newStr)
+	}
+	return []byte(res)
+}
+
+// rewrites returns the replacements of the file, main.*.js, main.*.css and asset-manifest.json refer to the other assets
+func rewrites(filePath string) []replacement {
+	name := path.Base(filePath)
+	if name == "asset-manifest.json" {
+		return []replacement{{old: "\"/static"}}
+	}
+	if strings.Split(name, ".")[0] != "main" {
+		return nil
+	}
+	switch strings.ToLower(path.Ext(name)) {
+	case ".js", ".map":
+		return []replacement{{old: "\"static"}, {old: "=\"/\",", new: "=\"\","}}
+	case ".css":
+		return []replacement{{old: "url(/static", new: "url(../../static"}}
+	}
+	return nil
+}
+
+func (s *Syncer) checkAvailable(objectKey string) error {
+	url := s.VisitURLPrefix + objectKey
+	response, err := http.Head(url)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get object %s, %s", url, response.Status)
+	}
+	return nil
+}
+
+func (s *Syncer) logf(format string, args ...any) {
+	if s.Logf != nil {
+		s.Logf(format, args...)
+	}
+}
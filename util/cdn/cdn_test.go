@@ -0,0 +1,233 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cdn
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type memoryProvider struct {
+	mu      sync.Mutex
+	objects map[string]string
+	headers map[string]*Object
+	puts    []string
+	err     error
+}
+
+func newMemoryProvider() *memoryProvider {
+	return &memoryProvider{objects: map[string]string{}, headers: map[string]*Object{}}
+}
+
+func (p *memoryProvider) GetObject(key string) ([]byte, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	content, ok := p.objects[key]
+	if !ok {
+		return nil, ErrObjectNotExist
+	}
+	return []byte(content), nil
+}
+
+func (p *memoryProvider) PutObject(object *Object) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	content, err := io.ReadAll(object.Body)
+	if err != nil {
+		return err
+	}
+	if int64(len(content)) != object.Size {
+		return errors.New("size mismatch")
+	}
+	p.objects[object.Key] = string(content)
+	p.headers[object.Key] = object
+	p.puts = append(p.puts, object.Key)
+	return nil
+}
+
+var fileTypes = map[string]bool{".js": true, ".css": true, ".json": true, ".map": true, ".png": true}
+
+func testBuild() fstest.MapFS {
+	return fstest.MapFS{
+		"index.html":                     {Data: []byte("<html></html>")},
+		"asset-manifest.json":            {Data: []byte(`{"main.js": "/static/js/main.1.js"}`)},
+		"static/js/main.1.js":            {Data: []byte(`a="static/js/1.chunk.js",p="/",`)},
+		"static/js/main.1.js.map":        {Data: []byte(`{"file":"static/js/main.1.js"}`)},
+		"static/js/2.chunk.js":           {Data: []byte(`"static/js/3.chunk.js"`)},
+		"static/css/main.1.css":          {Data: []byte(`body{background:url(/static/media/bg.png)}`)},
+		"static/media/bg.png":            {Data: []byte("png")},
+		"static/media/large.png":         {Data: []byte(strings.Repeat("x", 200))},
+		"static/media/nested/deep/a.css": {Data: []byte("a{}")},
+	}
+}
+
+func TestSyncer_Sync(t *testing.T) {
+	p := newMemoryProvider()
+	s := &Syncer{
+		Provider:       p,
+		VisitURLPrefix: "https://cdn.example.com/",
+		KeyPrefix:      "answer/",
+		MaxFileSize:    100,
+		FileTypes:      fileTypes,
+	}
+	result, err := s.Sync(testBuild())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Uploaded != 7 || result.Skipped != 2 || result.Unchanged != 0 {
+		t.Errorf("Sync() = %+v", result)
+	}
+	manifest := &Manifest{}
+	if err = json.Unmarshal([]byte(p.objects["answer/"+ManifestName]), manifest); err != nil || len(manifest.Files) != 7 {
+		t.Fatalf("manifest = %v, %v", manifest, err)
+	}
+	delete(p.objects, "answer/"+ManifestName)
+
+	want := map[string]string{
+		"answer/asset-manifest.json":            `{"main.js": "https://cdn.example.com/answer/static/js/main.1.js"}`,
+		"answer/static/js/main.1.js":            `a="https://cdn.example.com/answer/static/js/1.chunk.js",p="",`,
+		"answer/static/js/main.1.js.map":        `{"file":"https://cdn.example.com/answer/static/js/main.1.js"}`,
+		"answer/static/js/2.chunk.js":           `"static/js/3.chunk.js"`,
+		"answer/static/css/main.1.css":          `body{background:url(../../static/media/bg.png)}`,
+		"answer/static/media/bg.png":            "png",
+		"answer/static/media/nested/deep/a.css": "a{}",
+	}
+	if len(p.objects) != len(want) {
+		t.Errorf("uploaded %d objects, want %d: %v", len(p.objects), len(want), p.objects)
+	}
+	for key, content := range want {
+		if got, ok := p.objects[key]; !ok || got != content {
+			t.Errorf("object %s = %q, want %q", key, got, content)
+		}
+	}
+}
+
+func TestSyncer_SyncProviderError(t *testing.T) {
+	s := &Syncer{
+		Provider:   &memoryProvider{err: errors.New("denied")},
+		FileTypes:  fileTypes,
+		RetryDelay: time.Millisecond,
+	}
+	if _, err := s.Sync(testBuild()); err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Errorf("Sync() = %v, want the provider error", err)
+	}
+}
+
+func TestSyncer_SyncIncremental(t *testing.T) {
+	p := newMemoryProvider()
+	s := &Syncer{Provider: p, FileTypes: fileTypes}
+	build := testBuild()
+	// without a manifest the objects of an earlier upload may be overwritten, all of them are changed
+	p.objects["static/js/2.chunk.js"] = "uploaded without a manifest"
+	result, err := s.Sync(build)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Changed) != result.Uploaded || !slices.Contains(result.Changed, "static/js/2.chunk.js") {
+		t.Errorf("first Sync() changed %v of %d uploaded", result.Changed, result.Uploaded)
+	}
+
+	p.puts = nil
+	result, err = s.Sync(build)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Uploaded != 0 || result.Unchanged != 8 || len(p.puts) != 0 {
+		t.Errorf("Sync() of an unchanged build = %+v, uploaded %v", result, p.puts)
+	}
+
+	build["static/js/2.chunk.js"] = &fstest.MapFile{Data: []byte("changed")}
+	build["static/js/4.chunk.js"] = &fstest.MapFile{Data: []byte("new")}
+	result, err = s.Sync(build)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPuts := []string{ManifestName, "static/js/2.chunk.js", "static/js/4.chunk.js"}
+	sort.Strings(p.puts)
+	if result.Uploaded != 2 || strings.Join(p.puts, ",") != strings.Join(wantPuts, ",") {
+		t.Errorf("Sync() of a changed build = %+v, uploaded %v", result, p.puts)
+	}
+	// the new file is not cached by the CDN yet
+	if strings.Join(result.Changed, ",") != "static/js/2.chunk.js" {
+		t.Errorf("Sync() changed %v", result.Changed)
+	}
+
+	// a different visit url changes the rewritten main.*.js, its map and asset-manifest.json
+	p.puts = nil
+	s.VisitURLPrefix = "https://cdn.example.com/"
+	if _, err = s.Sync(build); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.puts) != 4 {
+		t.Errorf("Sync() with another visit url uploaded %v", p.puts)
+	}
+}
+
+func TestSyncer_SyncBrokenManifest(t *testing.T) {
+	p := newMemoryProvider()
+	p.objects[ManifestName] = "not json"
+	s := &Syncer{Provider: p, FileTypes: fileTypes}
+	result, err := s.Sync(testBuild())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Uploaded != 8 || p.objects[ManifestName] == "not json" {
+		t.Errorf("Sync() with a broken manifest = %+v", result)
+	}
+}
+
+func TestSyncer_CheckAvailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("method = %s, want HEAD", r.Method)
+		}
+		if r.URL.Path != "/static/media/bg.png" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	s := &Syncer{
+		Provider:       newMemoryProvider(),
+		VisitURLPrefix: server.URL + "/",
+		FileTypes:      map[string]bool{".png": true},
+		CheckAvailable: true,
+	}
+	if _, err := s.Sync(fstest.MapFS{"static/media/bg.png": {Data: []byte("png")}}); err != nil {
+		t.Errorf("Sync() = %v", err)
+	}
+	if _, err := s.Sync(fstest.MapFS{"static/media/other.png": {Data: []byte("png")}}); err == nil {
+		t.Error("Sync() passed an object the CDN does not serve")
+	}
+}
@@ -0,0 +1,250 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cdn
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+)
+
+// ReleasesName is the name of the object listing the releases, it is stored under the key prefix
+const ReleasesName = "answer-cdn-releases.json"
+
+// Cleanup modes of the releases older than the kept ones
+const (
+	// CleanupOff keeps all the releases
+	CleanupOff = "off"
+	// CleanupDryRun only logs the objects which would be deleted
+	CleanupDryRun = "dry_run"
+	// CleanupDelete deletes the objects of the old releases
+	CleanupDelete = "delete"
+)
+
+// releaseLength is the number of hex characters of the sha256 naming a release
+const releaseLength = 12
+
+// CleanupProvider is a Provider able to list and delete objects, it is needed by the Cleaner only
+type CleanupProvider interface {
+	Provider
+	// ListObjects returns the keys of all the objects starting with the prefix
+	ListObjects(prefix string) ([]string, error)
+	// DeleteObjects deletes the objects, the provider splits them into requests of its size limit
+	DeleteObjects(keys []string) error
+}
+
+// Releases lists the releases uploaded under the key prefix, the oldest first
+type Releases struct {
+	Releases []string `json:"releases"`
+}
+
+// CleanResult lists the releases removed by a cleanup, or the ones which would be removed in dry run
+type CleanResult struct {
+	Removed []string
+	// Unversioned is the number of removed objects under the key prefix which belong to no release
+	Unversioned int
+	Objects     int
+}
+
+// Cleaner removes the releases older than the kept ones. Every release is uploaded under
+// the key prefix followed by its name, see Release. The other objects under the key prefix are left alone,
+// unless CleanUnversioned is set.
+type Cleaner struct {
+	Provider CleanupProvider
+	// KeyPrefix is the prefix of the releases, it ends with "/" when not empty
+	KeyPrefix string
+	// Keep is the number of releases kept besides the current one
+	Keep int
+	// Mode is one of CleanupOff, CleanupDryRun and CleanupDelete, empty means off
+	Mode string
+	// CleanUnversioned removes the other objects under the key prefix as well, such as the ones uploaded
+	// before the versioned prefix was enabled, they count as a release older than all the others.
+	// Only for a key prefix holding nothing but the static files, it is ignored without a key prefix.
+	CleanUnversioned bool
+	// Logf reports the objects in dry run, it is optional
+	Logf func(format string, args ...any)
+}
+
+// Release names the ui build by the sha256 of the paths and the contents of its files,
+// so the objects of a build are uploaded under a prefix of their own
+func Release(build fs.FS) (string, error) {
+	h := sha256.New()
+	err := fs.WalkDir(build, ".", func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		content, err := fs.ReadFile(build, filePath)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(h, "%s\n%d\n", filePath, len(content))
+		h.Write(content)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil))[:releaseLength], nil
+}
+
+// Clean records release as the current one and removes the releases before the kept ones.
+// A release failing to be deleted stays in the list and is deleted by the next cleanup.
+func (c *Cleaner) Clean(release string) (*CleanResult, error) {
+	loaded, err := c.loadReleases()
+	if err != nil {
+		return nil, err
+	}
+	releases := append(slices.DeleteFunc(slices.Clone(loaded), func(r string) bool {
+		return r == release
+	}), release)
+
+	result := &CleanResult{}
+	if c.Mode == CleanupDryRun || c.Mode == CleanupDelete {
+		err = c.clean(releases, result)
+		if c.Mode == CleanupDelete {
+			releases = releases[len(result.Removed):]
+		}
+	}
+
+	if !slices.Equal(loaded, releases) {
+		if perr := c.putReleases(releases); perr != nil {
+			return result, errors.Join(err, perr)
+		}
+	}
+	return result, err
+}
+
+// clean removes the objects of the releases before the kept ones, the oldest first
+func (c *Cleaner) clean(releases []string, result *CleanResult) error {
+	objects, err := c.listObjects(releases)
+	if err != nil {
+		return err
+	}
+	// the unversioned objects are older than any release
+	candidates := releases
+	if len(objects[unversioned]) > 0 {
+		candidates = append([]string{unversioned}, releases...)
+	}
+	stale := candidates[:max(len(candidates)-max(c.Keep, 0)-1, 0)]
+	for _, r := range stale {
+		keys := objects[r]
+		if c.Mode == CleanupDryRun {
+			for _, key := range keys {
+				c.logf("dry run: delete %s", key)
+			}
+		} else if len(keys) > 0 {
+			if err = c.Provider.DeleteObjects(keys); err != nil {
+				if r == unversioned {
+					return fmt.Errorf("delete unversioned objects failed: %w", err)
+				}
+				return fmt.Errorf("delete release %s failed: %w", r, err)
+			}
+		}
+		if r == unversioned {
+			result.Unversioned = len(keys)
+		} else {
+			result.Removed = append(result.Removed, r)
+		}
+		result.Objects += len(keys)
+	}
+	return nil
+}
+
+// unversioned groups the objects under the key prefix which belong to no release
+const unversioned = ""
+
+// listObjects lists the objects of the releases, and the unversioned ones when they are cleaned up.
+// Without a key prefix the objects of the whole bucket would be unversioned, so they never are.
+func (c *Cleaner) listObjects(releases []string) (map[string][]string, error) {
+	objects := make(map[string][]string, len(releases)+1)
+	if !c.CleanUnversioned || len(c.KeyPrefix) == 0 {
+		for _, r := range releases {
+			keys, err := c.Provider.ListObjects(c.KeyPrefix + r + "/")
+			if err != nil {
+				return nil, fmt.Errorf("list release %s failed: %w", r, err)
+			}
+			objects[r] = keys
+		}
+		return objects, nil
+	}
+
+	keys, err := c.Provider.ListObjects(c.KeyPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("list objects failed: %w", err)
+	}
+	for _, key := range keys {
+		name := strings.TrimPrefix(key, c.KeyPrefix)
+		if name == ReleasesName {
+			continue
+		}
+		r, _, _ := strings.Cut(name, "/")
+		if !slices.Contains(releases, r) {
+			r = unversioned
+		}
+		objects[r] = append(objects[r], key)
+	}
+	return objects, nil
+}
+
+// loadReleases returns the recorded releases, unlike the manifest an unreadable list fails the cleanup,
+// as the releases it lists would never be deleted
+func (c *Cleaner) loadReleases() ([]string, error) {
+	data, err := c.Provider.GetObject(c.KeyPrefix + ReleasesName)
+	if errors.Is(err, ErrObjectNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read releases failed: %w", err)
+	}
+	releases := &Releases{}
+	if err = json.Unmarshal(data, releases); err != nil {
+		return nil, fmt.Errorf("parse releases failed: %w", err)
+	}
+	return releases.Releases, nil
+}
+
+func (c *Cleaner) putReleases(releases []string) error {
+	data, _ := json.Marshal(&Releases{Releases: releases})
+	object := &Object{
+		Key:          c.KeyPrefix + ReleasesName,
+		Path:         ReleasesName,
+		ContentType:  ContentType(ReleasesName, data),
+		CacheControl: "no-cache",
+	}
+	object.setContent(data)
+	if err := c.Provider.PutObject(object); err != nil {
+		return fmt.Errorf("upload %s failed: %w", object.Key, err)
+	}
+	return nil
+}
+
+func (c *Cleaner) logf(format string, args ...any) {
+	if c.Logf != nil {
+		c.Logf(format, args...)
+	}
+}
@@ -0,0 +1,258 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cdn
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func (p *memoryProvider) ListObjects(prefix string) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var keys []string
+	for key := range p.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (p *memoryProvider) DeleteObjects(keys []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	for _, key := range keys {
+		delete(p.objects, key)
+	}
+	return nil
+}
+
+func TestRelease(t *testing.T) {
+	build := testBuild()
+	first, err := Release(build)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := Release(build); again != first || len(first) != releaseLength {
+		t.Errorf("Release() = %s and %s", first, again)
+	}
+	build["static/js/4.chunk.js"] = &fstest.MapFile{Data: []byte("new")}
+	if changed, _ := Release(build); changed == first {
+		t.Error("Release() of a changed build is the same")
+	}
+}
+
+func TestCleaner_Clean(t *testing.T) {
+	p := newMemoryProvider()
+	for _, release := range []string{"r1", "r2", "r3", "r4"} {
+		p.objects["answer/"+release+"/static/js/main.js"] = release
+		p.objects["answer/"+release+"/"+ManifestName] = "{}"
+	}
+	p.objects["answer/favicon.ico"] = "not a release"
+
+	c := &Cleaner{Provider: p, KeyPrefix: "answer/", Keep: 1, CleanUnversioned: true}
+	for _, release := range []string{"r1", "r2", "r3", "r4"} {
+		if _, err := c.Clean(release); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(p.objects) != 10 {
+		t.Fatalf("Clean() while off deleted objects: %v", p.objects)
+	}
+
+	c.Mode = CleanupDryRun
+	var logged []string
+	c.Logf = func(format string, args ...any) {
+		logged = append(logged, args[0].(string))
+	}
+	result, err := c.Clean("r4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(result.Removed, ",") != "r1,r2" || result.Unversioned != 1 || result.Objects != 5 || len(logged) != 5 || len(p.objects) != 10 {
+		t.Errorf("Clean() in dry run = %+v, logged %v", result, logged)
+	}
+
+	c.Mode = CleanupDelete
+	result, err = c.Clean("r4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(result.Removed, ",") != "r1,r2" || result.Unversioned != 1 || result.Objects != 5 {
+		t.Errorf("Clean() = %+v", result)
+	}
+	keys, _ := p.ListObjects("answer/")
+	want := []string{
+		"answer/" + ReleasesName,
+		"answer/r3/" + ManifestName,
+		"answer/r3/static/js/main.js",
+		"answer/r4/" + ManifestName,
+		"answer/r4/static/js/main.js",
+	}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Errorf("objects after Clean() = %v", keys)
+	}
+	if p.objects["answer/"+ReleasesName] != `{"releases":["r3","r4"]}` {
+		t.Errorf("releases = %s", p.objects["answer/"+ReleasesName])
+	}
+
+	// an earlier release becomes the current one again
+	if _, err = c.Clean("r3"); err != nil {
+		t.Fatal(err)
+	}
+	if p.objects["answer/"+ReleasesName] != `{"releases":["r4","r3"]}` {
+		t.Errorf("releases = %s", p.objects["answer/"+ReleasesName])
+	}
+}
+
+func TestCleaner_CleanUnversioned(t *testing.T) {
+	p := newMemoryProvider()
+	// uploaded before the versioned prefix was enabled
+	for _, key := range []string{ManifestName, "static/js/main.js", "static/css/main.css"} {
+		p.objects["answer/"+key] = "unversioned"
+	}
+	p.objects["other/static/js/main.js"] = "outside the key prefix"
+	p.objects["answer/r1/static/js/main.js"] = "r1"
+
+	c := &Cleaner{Provider: p, KeyPrefix: "answer/", Keep: 1, Mode: CleanupDelete, CleanUnversioned: true}
+	result, err := c.Clean("r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the unversioned objects are the kept previous release
+	if result.Unversioned != 0 || result.Objects != 0 || len(p.objects) != 6 {
+		t.Errorf("Clean() = %+v, objects %v", result, p.objects)
+	}
+
+	p.objects["answer/r2/static/js/main.js"] = "r2"
+	c.Mode = CleanupDryRun
+	if result, err = c.Clean("r2"); err != nil {
+		t.Fatal(err)
+	}
+	if result.Unversioned != 3 || len(result.Removed) != 0 || len(p.objects) != 7 {
+		t.Errorf("Clean() in dry run = %+v, objects %v", result, p.objects)
+	}
+
+	c.Mode = CleanupDelete
+	if result, err = c.Clean("r2"); err != nil {
+		t.Fatal(err)
+	}
+	if result.Unversioned != 3 || result.Objects != 3 || len(result.Removed) != 0 {
+		t.Errorf("Clean() = %+v", result)
+	}
+	keys, _ := p.ListObjects("")
+	want := []string{
+		"answer/" + ReleasesName,
+		"answer/r1/static/js/main.js",
+		"answer/r2/static/js/main.js",
+		"other/static/js/main.js",
+	}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Errorf("objects after Clean() = %v", keys)
+	}
+}
+
+func TestCleaner_CleanKeepsOtherObjects(t *testing.T) {
+	p := newMemoryProvider()
+	// the key prefix is shared with the uploads of a storage plugin
+	others := []string{"answer/uploads/avatar.png", "answer/static/js/main.js", "answer/r1.txt"}
+	for _, key := range others {
+		p.objects[key] = "not a release"
+	}
+	for _, release := range []string{"r1", "r2", "r3"} {
+		p.objects["answer/"+release+"/static/js/main.js"] = release
+	}
+
+	c := &Cleaner{Provider: p, KeyPrefix: "answer/", Mode: CleanupDelete}
+	for _, release := range []string{"r1", "r2", "r3"} {
+		if _, err := c.Clean(release); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keys, _ := p.ListObjects("answer/")
+	want := []string{
+		"answer/" + ReleasesName,
+		"answer/r1.txt",
+		"answer/r3/static/js/main.js",
+		"answer/static/js/main.js",
+		"answer/uploads/avatar.png",
+	}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Errorf("objects after Clean() = %v", keys)
+	}
+}
+
+func TestCleaner_CleanWithoutKeyPrefix(t *testing.T) {
+	p := newMemoryProvider()
+	p.objects["uploads/avatar.png"] = "not a release"
+	p.objects["r1/static/js/main.js"] = "r1"
+	p.objects["r2/static/js/main.js"] = "r2"
+	c := &Cleaner{Provider: p, Mode: CleanupDelete, CleanUnversioned: true}
+	if _, err := c.Clean("r1"); err != nil {
+		t.Fatal(err)
+	}
+	result, err := c.Clean("r2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// without a key prefix the other objects of the bucket are never unversioned
+	if strings.Join(result.Removed, ",") != "r1" || result.Unversioned != 0 || result.Objects != 1 {
+		t.Errorf("Clean() = %+v", result)
+	}
+	if _, ok := p.objects["uploads/avatar.png"]; !ok || len(p.objects) != 3 {
+		t.Errorf("objects after Clean() = %v", p.objects)
+	}
+}
+
+func TestCleaner_CleanError(t *testing.T) {
+	p := newMemoryProvider()
+	p.objects["answer/"+ReleasesName] = `{"releases":["r1","r2"]}`
+	p.objects["answer/r1/static/js/main.js"] = "r1"
+	p.objects["answer/r2/static/js/main.js"] = "r2"
+	c := &Cleaner{Provider: &failingDeleter{memoryProvider: p}, KeyPrefix: "answer/", Mode: CleanupDelete}
+	if _, err := c.Clean("r3"); err == nil {
+		t.Fatal("Clean() passed a failed deletion")
+	}
+	// the releases failing to be deleted are kept for the next cleanup
+	if p.objects["answer/"+ReleasesName] != `{"releases":["r1","r2","r3"]}` {
+		t.Errorf("releases = %s", p.objects["answer/"+ReleasesName])
+	}
+
+	p.objects["answer/"+ReleasesName] = "not json"
+	if _, err := c.Clean("r3"); err == nil {
+		t.Error("Clean() passed unreadable releases")
+	}
+}
+
+type failingDeleter struct {
+	*memoryProvider
+}
+
+func (p *failingDeleter) DeleteObjects(keys []string) error {
+	return errors.New("denied")
+}
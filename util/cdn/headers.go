@@ -0,0 +1,166 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cdn
+
+import (
+	"bytes"
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Compression modes of the uploaded objects
+const (
+	// CompressionNone uploads the files as they are
+	CompressionNone = "none"
+	// CompressionGzip uploads the gzip compressed files with Content-Encoding: gzip, every browser accepts them
+	CompressionGzip = "gzip"
+	// CompressionVariants uploads the files as they are, together with gzip and brotli compressed
+	// variants at the key with ".gz" and ".br" appended, for CDNs picking the variant by Accept-Encoding
+	CompressionVariants = "variants"
+)
+
+const (
+	// ImmutableCacheControl is used for the files with a content hash in the name, they never change
+	ImmutableCacheControl = "public, max-age=31536000, immutable"
+	// ShortCacheControl is used for the other files, e.g. asset-manifest.json, which change with every build
+	ShortCacheControl = "public, max-age=300"
+)
+
+// contentTypes are the types of the files in the ui build, mime.TypeByExtension depends on the system
+var contentTypes = map[string]string{
+	".css":   "text/css; charset=utf-8",
+	".ico":   "image/x-icon",
+	".jpg":   "image/jpeg",
+	".jpeg":  "image/jpeg",
+	".js":    "text/javascript; charset=utf-8",
+	".json":  "application/json",
+	".map":   "application/json",
+	".png":   "image/png",
+	".svg":   "image/svg+xml",
+	".txt":   "text/plain; charset=utf-8",
+	".webp":  "image/webp",
+	".woff":  "font/woff",
+	".woff2": "font/woff2",
+}
+
+// compressible are the extensions worth compressing, images and fonts are compressed already
+var compressible = map[string]bool{
+	".css":  true,
+	".ico":  true,
+	".js":   true,
+	".json": true,
+	".map":  true,
+	".svg":  true,
+	".txt":  true,
+}
+
+// hashedName matches the names with a content hash, e.g. main.1a2b3c4d.js or 2.1a2b3c4d.chunk.js
+var hashedName = regexp.MustCompile(`\.[0-9a-f]{8,}\.`)
+
+// ContentType returns the mime type of the file by the extension, or by the content when the extension is unknown
+func ContentType(filePath string, content []byte) string {
+	ext := strings.ToLower(path.Ext(filePath))
+	if contentType, ok := contentTypes[ext]; ok {
+		return contentType
+	}
+	if contentType := mime.TypeByExtension(ext); len(contentType) > 0 {
+		return contentType
+	}
+	return http.DetectContentType(content)
+}
+
+// CacheControl returns the cache control of the file, hashed files are cached forever
+func CacheControl(filePath string) string {
+	if hashedName.MatchString(path.Base(filePath)) {
+		return ImmutableCacheControl
+	}
+	return ShortCacheControl
+}
+
+// encode builds the objects uploaded for the file in the compression mode
+func encode(compression, filePath string, content []byte) ([]*Object, error) {
+	object := &Object{
+		Path:         filePath,
+		ContentType:  ContentType(filePath, content),
+		CacheControl: CacheControl(filePath),
+	}
+	if !compressible[strings.ToLower(path.Ext(filePath))] {
+		compression = CompressionNone
+	}
+	switch compression {
+	case CompressionGzip:
+		compressed, err := gzipContent(content)
+		if err != nil {
+			return nil, err
+		}
+		object.ContentEncoding = "gzip"
+		object.setContent(compressed)
+		return []*Object{object}, nil
+	case CompressionVariants:
+		gz, err := gzipContent(content)
+		if err != nil {
+			return nil, err
+		}
+		br, err := brotliContent(content)
+		if err != nil {
+			return nil, err
+		}
+		gzObject, brObject := *object, *object
+		object.setContent(content)
+		gzObject.suffix, gzObject.ContentEncoding = ".gz", "gzip"
+		gzObject.setContent(gz)
+		brObject.suffix, brObject.ContentEncoding = ".br", "br"
+		brObject.setContent(br)
+		return []*Object{object, &gzObject, &brObject}, nil
+	default:
+		object.setContent(content)
+		return []*Object{object}, nil
+	}
+}
+
+func gzipContent(content []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w, _ := gzip.NewWriterLevel(buf, gzip.BestCompression)
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func brotliContent(content []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := brotli.NewWriterLevel(buf, brotli.BestCompression)
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,148 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cdn
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+	"testing/fstest"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestContentType(t *testing.T) {
+	tests := map[string]string{
+		"static/js/main.1a2b3c4d.js":       "text/javascript; charset=utf-8",
+		"static/css/main.1a2b3c4d.css":     "text/css; charset=utf-8",
+		"asset-manifest.json":              "application/json",
+		"static/js/main.1a2b3c4d.js.map":   "application/json",
+		"static/media/logo.1a2b3c4d.svg":   "image/svg+xml",
+		"static/media/bg.1a2b3c4d.png":     "image/png",
+		"favicon.ico":                      "image/x-icon",
+		"static/media/font.1a2b3c4d.woff2": "font/woff2",
+	}
+	for filePath, want := range tests {
+		if got := ContentType(filePath, nil); got != want {
+			t.Errorf("ContentType(%s) = %s, want %s", filePath, got, want)
+		}
+	}
+	if got := ContentType("LICENSE", []byte("plain text")); got != "text/plain; charset=utf-8" {
+		t.Errorf("ContentType() by content = %s", got)
+	}
+}
+
+func TestCacheControl(t *testing.T) {
+	tests := map[string]string{
+		"static/js/main.1a2b3c4d.js":     ImmutableCacheControl,
+		"static/js/2.1a2b3c4d.chunk.js":  ImmutableCacheControl,
+		"static/js/main.1a2b3c4d.js.map": ImmutableCacheControl,
+		"static/media/bg.1a2b3c4d.png":   ImmutableCacheControl,
+		"asset-manifest.json":            ShortCacheControl,
+		"favicon.ico":                    ShortCacheControl,
+		"static/js/main.js":              ShortCacheControl,
+	}
+	for filePath, want := range tests {
+		if got := CacheControl(filePath); got != want {
+			t.Errorf("CacheControl(%s) = %s, want %s", filePath, got, want)
+		}
+	}
+}
+
+func TestSyncer_SyncCompression(t *testing.T) {
+	js := bytes.Repeat([]byte("console.log(1);"), 100)
+	build := fstest.MapFS{
+		"static/js/2.1a2b3c4d.chunk.js": {Data: js},
+		"static/media/bg.1a2b3c4d.png":  {Data: []byte("png")},
+	}
+	tests := []struct {
+		compression string
+		keys        map[string]string
+	}{
+		{
+			compression: CompressionNone,
+			keys:        map[string]string{"static/js/2.1a2b3c4d.chunk.js": ""},
+		},
+		{
+			compression: CompressionGzip,
+			keys:        map[string]string{"static/js/2.1a2b3c4d.chunk.js": "gzip"},
+		},
+		{
+			compression: CompressionVariants,
+			keys: map[string]string{
+				"static/js/2.1a2b3c4d.chunk.js":    "",
+				"static/js/2.1a2b3c4d.chunk.js.gz": "gzip",
+				"static/js/2.1a2b3c4d.chunk.js.br": "br",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.compression, func(t *testing.T) {
+			p := newMemoryProvider()
+			s := &Syncer{Provider: p, FileTypes: fileTypes, Compression: tt.compression}
+			if _, err := s.Sync(build); err != nil {
+				t.Fatal(err)
+			}
+			// the manifest, the png and the js objects
+			if len(p.objects) != len(tt.keys)+2 {
+				t.Errorf("uploaded %v", p.puts)
+			}
+			if png := p.headers["static/media/bg.1a2b3c4d.png"]; png == nil || png.ContentEncoding != "" {
+				t.Error("png is compressed")
+			}
+			if manifest := p.headers[ManifestName]; manifest == nil || manifest.ContentEncoding != "" {
+				t.Error("manifest is compressed")
+			}
+			for key, encoding := range tt.keys {
+				object := p.headers[key]
+				if object == nil {
+					t.Fatalf("%s is not uploaded", key)
+				}
+				if object.ContentEncoding != encoding || object.ContentType != "text/javascript; charset=utf-8" ||
+					object.CacheControl != ImmutableCacheControl {
+					t.Errorf("%s headers = %s, %s, %s", key, object.ContentEncoding, object.ContentType, object.CacheControl)
+				}
+				if got := decode(t, encoding, p.objects[key]); !bytes.Equal(got, js) {
+					t.Errorf("%s content does not decode to the file", key)
+				}
+			}
+		})
+	}
+}
+
+func decode(t *testing.T, encoding, content string) []byte {
+	var r io.Reader = bytes.NewReader([]byte(content))
+	switch encoding {
+	case "gzip":
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = gr
+	case "br":
+		r = brotli.NewReader(r)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,187 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cdn
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// StatusError is a request to the storage failed with an http status. Providers return it for
+// the errors of SDKs which don't expose the status by a StatusCode method, so Retryable can check it.
+type StatusError struct {
+	Status int
+	Err    error
+}
+
+func (e *StatusError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+func (e *StatusError) StatusCode() int {
+	return e.Status
+}
+
+// Retryable reports whether a failed upload is worth retrying: 5xx responses, throttling, timeouts
+// and dropped connections. Other failures, such as denied access or a missing bucket, won't pass
+// on a retry either. The status is read from any error in the chain with a StatusCode method.
+func Retryable(err error) bool {
+	var status interface{ StatusCode() int }
+	if errors.As(err, &status) && status.StatusCode() > 0 {
+		code := status.StatusCode()
+		return code >= http.StatusInternalServerError ||
+			code == http.StatusTooManyRequests || code == http.StatusRequestTimeout
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// upload puts the files with a pool of workers, it stops handing out files after the first failure
+// and returns the files uploaded before it
+func (s *Syncer) upload(files []*file) ([]*file, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	workers := s.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	workers = min(workers, len(files))
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		uploaded = make([]*file, 0, len(files))
+		firstErr error
+	)
+	step := max(len(files)/10, 1)
+	tasks := make(chan *file)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range tasks {
+				keys, err := s.put(f.path, f.content)
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+				} else {
+					f.keys = keys
+					uploaded = append(uploaded, f)
+					if s.Progress != nil && (len(uploaded)%step == 0 || len(uploaded) == len(files)) {
+						s.Progress(len(uploaded), len(files))
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, f := range files {
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+		tasks <- f
+	}
+	close(tasks)
+	wg.Wait()
+	return uploaded, firstErr
+}
+
+// retry puts the object until it succeeds, the retries run out or it fails for a reason a retry can't fix
+func (s *Syncer) retry(object *Object) error {
+	retries := s.Retries
+	if retries <= 0 {
+		retries = defaultRetries
+	}
+	delay := s.RetryDelay
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+	for attempt := 0; ; attempt++ {
+		if _, err := object.Body.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		err := s.Provider.PutObject(object)
+		if err == nil || attempt >= retries || !Retryable(err) {
+			return err
+		}
+		s.logf("upload %s failed, retry in %s: %v", object.Key, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// StaticPrefix is the prefix the static files are loaded from. It only changes when a sync succeeds,
+// so the pages never refer to a CDN missing some of the files.
+type StaticPrefix struct {
+	mu         sync.Mutex
+	generation int64
+	prefix     string
+}
+
+// Begin starts a sync, the generation is passed to Complete when it succeeds
+func (p *StaticPrefix) Begin() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.generation++
+	return p.generation
+}
+
+// Complete switches to the prefix of a succeeded sync, unless a later sync has begun since
+func (p *StaticPrefix) Complete(generation int64, prefix string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if generation == p.generation {
+		p.prefix = prefix
+	}
+}
+
+// Latest reports whether no later sync has begun since the generation
+func (p *StaticPrefix) Latest(generation int64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return generation == p.generation
+}
+
+// Get returns the prefix of the last succeeded sync, empty before any
+func (p *StaticPrefix) Get() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.prefix
+}
@@ -0,0 +1,169 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cdn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// flakyProvider fails the first puts of every key, and all the puts of the broken key with err
+type flakyProvider struct {
+	*memoryProvider
+	failures int
+	broken   string
+	err      error
+	attempts map[string]int
+}
+
+func (p *flakyProvider) PutObject(object *Object) error {
+	p.mu.Lock()
+	p.attempts[object.Key]++
+	attempt := p.attempts[object.Key]
+	p.mu.Unlock()
+	if object.Key == p.broken {
+		return p.err
+	}
+	if attempt <= p.failures {
+		return &StatusError{Status: http.StatusServiceUnavailable, Err: errors.New("slow down")}
+	}
+	return p.memoryProvider.PutObject(object)
+}
+
+func TestSyncer_SyncRetry(t *testing.T) {
+	p := &flakyProvider{memoryProvider: newMemoryProvider(), failures: 2, attempts: map[string]int{}}
+	var (
+		mu       sync.Mutex
+		progress []int
+	)
+	s := &Syncer{
+		Provider:   p,
+		FileTypes:  fileTypes,
+		Workers:    3,
+		RetryDelay: time.Millisecond,
+		Progress: func(uploaded, total int) {
+			mu.Lock()
+			defer mu.Unlock()
+			if total != 8 {
+				t.Errorf("Progress() total = %d, want 8", total)
+			}
+			progress = append(progress, uploaded)
+		},
+	}
+	result, err := s.Sync(testBuild())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Uploaded != 8 || len(p.objects) != 9 {
+		t.Errorf("Sync() = %+v, uploaded %v", result, p.puts)
+	}
+	if len(progress) != 8 || progress[len(progress)-1] != 8 {
+		t.Errorf("Progress() reported %v", progress)
+	}
+}
+
+func TestSyncer_SyncResume(t *testing.T) {
+	p := &flakyProvider{memoryProvider: newMemoryProvider(), broken: "static/js/2.chunk.js",
+		err: fmt.Errorf("put failed: %w", io.ErrUnexpectedEOF), attempts: map[string]int{}}
+	s := &Syncer{Provider: p, FileTypes: fileTypes, Workers: 1, RetryDelay: time.Millisecond}
+	build := testBuild()
+	result, err := s.Sync(build)
+	if err == nil || !strings.Contains(err.Error(), "static/js/2.chunk.js") {
+		t.Fatalf("Sync() = %v, want the failure of 2.chunk.js", err)
+	}
+	if _, ok := p.objects[ManifestName]; !ok || result.Uploaded == 0 {
+		t.Fatalf("Sync() = %+v, the uploaded files are not recorded", result)
+	}
+
+	if p.attempts["static/js/2.chunk.js"] != defaultRetries+1 {
+		t.Errorf("2.chunk.js is tried %d times", p.attempts["static/js/2.chunk.js"])
+	}
+
+	uploaded := result.Uploaded
+	p.broken = ""
+	result, err = s.Sync(build)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Unchanged != uploaded || result.Uploaded != 8-uploaded {
+		t.Errorf("Sync() after a failure = %+v, want %d unchanged", result, uploaded)
+	}
+}
+
+func TestSyncer_SyncPermanentFailure(t *testing.T) {
+	p := &flakyProvider{memoryProvider: newMemoryProvider(), broken: "static/js/2.chunk.js",
+		err: &StatusError{Status: http.StatusForbidden, Err: errors.New("access denied")}, attempts: map[string]int{}}
+	s := &Syncer{Provider: p, FileTypes: fileTypes, Workers: 1, RetryDelay: time.Hour}
+	if _, err := s.Sync(testBuild()); err == nil {
+		t.Fatal("Sync() passed a denied upload")
+	}
+	if p.attempts["static/js/2.chunk.js"] != 1 {
+		t.Errorf("denied 2.chunk.js is tried %d times", p.attempts["static/js/2.chunk.js"])
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&StatusError{Status: http.StatusServiceUnavailable, Err: errors.New("slow down")}, true},
+		{fmt.Errorf("put: %w", &StatusError{Status: http.StatusTooManyRequests, Err: errors.New("throttled")}), true},
+		{&StatusError{Status: http.StatusForbidden, Err: errors.New("access denied")}, false},
+		{&StatusError{Status: http.StatusNotFound, Err: errors.New("no such bucket")}, false},
+		{fmt.Errorf("put: %w", io.ErrUnexpectedEOF), true},
+		{&net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		{context.DeadlineExceeded, true},
+		{errors.New("invalid credentials"), false},
+	}
+	for _, tt := range tests {
+		if got := Retryable(tt.err); got != tt.want {
+			t.Errorf("Retryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestStaticPrefix(t *testing.T) {
+	p := &StaticPrefix{}
+	first := p.Begin()
+	second := p.Begin()
+	p.Complete(second, "https://b.example.com/")
+	p.Complete(first, "https://a.example.com/")
+	if p.Latest(first) || !p.Latest(second) {
+		t.Error("Latest() does not follow the last begun sync")
+	}
+	if got := p.Get(); got != "https://b.example.com/" {
+		t.Errorf("Get() = %s, an earlier sync overrides a later one", got)
+	}
+	p.Begin()
+	if got := p.Get(); got != "https://b.example.com/" {
+		t.Errorf("Get() = %s, want the last succeeded prefix during a sync", got)
+	}
+}
@@ -2,4 +2,7 @@ module github.com/apache/answer-plugins/util
 
 go 1.23.0
 
-require gopkg.in/yaml.v3 v3.0.1
+require (
+	github.com/andybalholm/brotli v1.2.6
+	gopkg.in/yaml.v3 v3.0.1
+)
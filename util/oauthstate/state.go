@@ -0,0 +1,158 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+// Package oauthstate binds the state parameter of an OAuth login to the browser that started it.
+// The connector issues a random state in ConnectorSender and keeps a signed copy in a cookie,
+// ConnectorReceiver then only accepts the callback when the returned state matches that cookie,
+// so a login can't be forged or fixed by sending a victim a callback url of another session.
+package oauthstate
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxAge is how long a user has to finish the login on the provider side.
+const DefaultMaxAge = 10 * time.Minute
+
+const stateBytes = 24
+
+var (
+	ErrStateMissing  = errors.New("oauth state is missing, the login was not started from this browser")
+	ErrStateMismatch = errors.New("oauth state does not match the one issued to this browser")
+	ErrStateExpired  = errors.New("oauth state has expired, please login again")
+)
+
+// processKey signs the cookies when the connector has no secret of its own.
+// It only lives as long as the process, so logins in flight fail after a restart.
+var processKey = func() []byte {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	return key
+}()
+
+// Cookie issues and verifies the state of one connector.
+type Cookie struct {
+	// Name tells the cookies of different connectors apart, usually the connector slug name.
+	Name string
+	// Secret signs the cookie, usually the client secret of the connector.
+	Secret string
+	// MaxAge defaults to DefaultMaxAge.
+	MaxAge time.Duration
+
+	now func() time.Time
+}
+
+// Issue generates a new state and sets its signed cookie on the response.
+// The returned state is sent to the provider as the state parameter.
+func (c *Cookie) Issue(w http.ResponseWriter, r *http.Request) (state string, err error) {
+	b := make([]byte, stateBytes)
+	if _, err = rand.Read(b); err != nil {
+		return "", err
+	}
+	state = base64.RawURLEncoding.EncodeToString(b)
+
+	expires := c.clock().Add(c.maxAge())
+	value := state + "." + strconv.FormatInt(expires.Unix(), 10)
+	value += "." + c.sign(value)
+	http.SetCookie(w, c.cookie(r, value, int(c.maxAge().Seconds())))
+	return state, nil
+}
+
+// Verify checks the state returned by the provider against the cookie of the request.
+// The cookie is cleared whatever the result, so each state can only be used once.
+func (c *Cookie) Verify(w http.ResponseWriter, r *http.Request, state string) error {
+	cookie, err := r.Cookie(c.cookieName())
+	if err != nil || len(cookie.Value) == 0 {
+		return ErrStateMissing
+	}
+	http.SetCookie(w, c.cookie(r, "", -1))
+
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 3 {
+		return ErrStateMismatch
+	}
+	signed := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(c.sign(signed))) {
+		return ErrStateMismatch
+	}
+	if len(state) == 0 || !hmac.Equal([]byte(parts[0]), []byte(state)) {
+		return ErrStateMismatch
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return ErrStateMismatch
+	}
+	if c.clock().Unix() > expires {
+		return ErrStateExpired
+	}
+	return nil
+}
+
+func (c *Cookie) cookie(r *http.Request, value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     c.cookieName(),
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		// Lax is required, the provider redirects back with a cross site top level navigation.
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func (c *Cookie) cookieName() string {
+	return "answer_oauth_state_" + c.Name
+}
+
+func (c *Cookie) sign(value string) string {
+	key := processKey
+	if len(c.Secret) > 0 {
+		key = []byte(c.Secret)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(c.cookieName() + "|" + value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (c *Cookie) maxAge() time.Duration {
+	if c.MaxAge > 0 {
+		return c.MaxAge
+	}
+	return DefaultMaxAge
+}
+
+func (c *Cookie) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
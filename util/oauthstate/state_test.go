@@ -0,0 +1,124 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package oauthstate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// issue starts a login and returns the state with the callback request carrying its cookie.
+func issue(t *testing.T, c *Cookie) (string, *http.Request) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	state, err := c.Issue(w, httptest.NewRequest(http.MethodGet, "/answer/api/v1/connector/login/github", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/answer/api/v1/connector/redirect/github?state="+state, nil)
+	for _, cookie := range w.Result().Cookies() {
+		if !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode {
+			t.Errorf("Issue() set cookie %+v", cookie)
+		}
+		r.AddCookie(cookie)
+	}
+	return state, r
+}
+
+func TestCookie_Verify(t *testing.T) {
+	c := &Cookie{Name: "github", Secret: "secret"}
+	state, r := issue(t, c)
+	if other, _ := issue(t, c); other == state {
+		t.Fatalf("Issue() returned the same state twice: %s", state)
+	}
+
+	w := httptest.NewRecorder()
+	if err := c.Verify(w, r, state); err != nil {
+		t.Fatalf("Verify() = %v", err)
+	}
+	cleared := w.Result().Cookies()
+	if len(cleared) != 1 || cleared[0].MaxAge >= 0 {
+		t.Errorf("Verify() did not clear the cookie: %+v", cleared)
+	}
+}
+
+func TestCookie_VerifyRejects(t *testing.T) {
+	c := &Cookie{Name: "github", Secret: "secret"}
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	tests := []struct {
+		name string
+		req  func() (*http.Request, string)
+		want error
+	}{
+		{"no cookie", func() (*http.Request, string) {
+			state, _ := issue(t, c)
+			return httptest.NewRequest(http.MethodGet, "/", nil), state
+		}, ErrStateMissing},
+		{"other state", func() (*http.Request, string) {
+			_, r := issue(t, c)
+			other, _ := issue(t, c)
+			return r, other
+		}, ErrStateMismatch},
+		{"empty state", func() (*http.Request, string) {
+			_, r := issue(t, c)
+			return r, ""
+		}, ErrStateMismatch},
+		{"other secret", func() (*http.Request, string) {
+			state, r := issue(t, &Cookie{Name: "github", Secret: "attacker"})
+			return r, state
+		}, ErrStateMismatch},
+		{"other connector", func() (*http.Request, string) {
+			state, r := issue(t, &Cookie{Name: "dingtalk", Secret: "secret"})
+			return r, state
+		}, ErrStateMissing},
+		{"expired", func() (*http.Request, string) {
+			state, r := issue(t, &Cookie{Name: "github", Secret: "secret", now: func() time.Time {
+				return now.Add(-DefaultMaxAge - time.Second)
+			}})
+			return r, state
+		}, ErrStateExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, state := tt.req()
+			if err := c.Verify(httptest.NewRecorder(), r, state); !errors.Is(err, tt.want) {
+				t.Errorf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCookie_Secure(t *testing.T) {
+	c := &Cookie{Name: "basic"}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Forwarded-Proto", "https")
+	w := httptest.NewRecorder()
+	if _, err := c.Issue(w, r); err != nil {
+		t.Fatal(err)
+	}
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || !cookies[0].Secure {
+		t.Errorf("Issue() behind https proxy set cookie %+v", cookies)
+	}
+}